@@ -2,6 +2,10 @@ package ast
 
 import (
 	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
 
 	"github.com/alex-davis-808/go-interpreter/src/interpreter/token"
 )
@@ -29,6 +33,9 @@ type Expression interface {
 // A Program is just a series of statements
 type Program struct {
 	Statements []Statement
+	// every comment in the source in order, tools can use their positions
+	// to attach them to the statements around them
+	Comments []*Comment
 }
 
 func (p *Program) TokenLiteral() string {
@@ -50,6 +57,15 @@ func (p *Program) String() string {
 	return out.String()
 }
 
+// Comment is a // or /* */ comment. comments aren't statements or expressions,
+// the parser only collects them when the lexer was asked to emit them
+type Comment struct {
+	Token token.Token // a 'COMMENT' token, its literal is the full text of the comment
+}
+
+func (c *Comment) TokenLiteral() string { return c.Token.Literal }
+func (c *Comment) String() string       { return c.Token.Literal }
+
 type LetStatement struct {
 	// it will have a 'LET' token type and it's literal "let"
 	Token token.Token
@@ -133,6 +149,8 @@ func (es *ExpressionStatement) String() string {
 type IntegerLiteral struct {
 	Token token.Token
 	Value int64
+	// set instead of Value when the literal doesn't fit in an int64
+	Big *big.Int
 }
 
 func (il *IntegerLiteral) expressionNode()      {}
@@ -180,3 +198,265 @@ func (oe *InfixExpression) String() string {
 
 	return out.String()
 }
+
+type Boolean struct {
+	Token token.Token // a 'TRUE' or 'FALSE' token
+	Value bool
+}
+
+func (b *Boolean) expressionNode()      {}
+func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
+func (b *Boolean) String() string       { return b.Token.Literal }
+
+type IfExpression struct {
+	Token       token.Token // the 'if' token
+	Condition   Expression
+	Consequence *BlockStatement
+	// nil when there is no else branch, an else-if chain is stored as a block
+	// holding the nested IfExpression with the nested 'if' as the block's token
+	Alternative *BlockStatement
+}
+
+func (ie *IfExpression) expressionNode()      {}
+func (ie *IfExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IfExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("if (")
+	out.WriteString(ie.Condition.String())
+	out.WriteString(") ")
+	out.WriteString(ie.Consequence.String())
+
+	if ie.Alternative != nil {
+		out.WriteString(" else ")
+		if ie.Alternative.isElseIf() {
+			out.WriteString(ie.Alternative.Statements[0].String())
+		} else {
+			out.WriteString(ie.Alternative.String())
+		}
+	}
+
+	return out.String()
+}
+
+type BlockStatement struct {
+	Token      token.Token // the '{' token
+	Statements []Statement
+	// position of the closing '}', not valid if the block wasn't closed
+	Rbrace token.Position
+}
+
+func (bs *BlockStatement) statementNode()       {}
+func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BlockStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("{ ")
+	for _, s := range bs.Statements {
+		out.WriteString(s.String())
+		// let and return statements already end in a semicolon, expression statements
+		// need one so the statements inside don't run together when parsed again
+		if _, ok := s.(*ExpressionStatement); ok {
+			out.WriteString(";")
+		}
+		out.WriteString(" ")
+	}
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// the parser wraps the nested if of an else-if in a block started by that 'if'
+func (bs *BlockStatement) isElseIf() bool {
+	return bs.Token.Type == token.IF && len(bs.Statements) == 1
+}
+
+type FunctionLiteral struct {
+	Token      token.Token // the 'fn' token
+	Parameters []*Identifier
+	Body       *BlockStatement
+}
+
+func (fl *FunctionLiteral) expressionNode()      {}
+func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FunctionLiteral) String() string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, p := range fl.Parameters {
+		params = append(params, p.String())
+	}
+
+	out.WriteString(fl.TokenLiteral())
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") ")
+	out.WriteString(fl.Body.String())
+
+	return out.String()
+}
+
+type CallExpression struct {
+	Token     token.Token // the '(' token
+	Function  Expression  // Identifier, FunctionLiteral or any expression producing a function
+	Arguments []Expression
+}
+
+func (ce *CallExpression) expressionNode()      {}
+func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *CallExpression) String() string {
+	var out bytes.Buffer
+
+	args := []string{}
+	for _, a := range ce.Arguments {
+		args = append(args, a.String())
+	}
+
+	out.WriteString(ce.Function.String())
+	out.WriteString("(")
+	out.WriteString(strings.Join(args, ", "))
+	out.WriteString(")")
+
+	return out.String()
+}
+
+type StringLiteral struct {
+	Token token.Token // the token's literal holds the value with escapes already replaced
+	Value string
+}
+
+func (sl *StringLiteral) expressionNode()      {}
+func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *StringLiteral) String() string       { return Quote(sl.Value) }
+
+// Quote returns s as a double quoted string literal the lexer reads back as s,
+// using the same escape sequences the lexer understands
+func Quote(s string) string {
+	var out bytes.Buffer
+
+	out.WriteByte('"')
+	for _, ch := range s {
+		switch {
+		case ch == '"':
+			out.WriteString(`\"`)
+		case ch == '\\':
+			out.WriteString(`\\`)
+		case ch == '\n':
+			out.WriteString(`\n`)
+		case ch == '\t':
+			out.WriteString(`\t`)
+		case unicode.IsPrint(ch):
+			out.WriteRune(ch)
+		default:
+			out.WriteString(fmt.Sprintf(`\u{%x}`, ch))
+		}
+	}
+	out.WriteByte('"')
+
+	return out.String()
+}
+
+type ArrayLiteral struct {
+	Token    token.Token // the '[' token
+	Elements []Expression
+}
+
+func (al *ArrayLiteral) expressionNode()      {}
+func (al *ArrayLiteral) TokenLiteral() string { return al.Token.Literal }
+func (al *ArrayLiteral) String() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, el := range al.Elements {
+		elements = append(elements, el.String())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+type IndexExpression struct {
+	Token token.Token // the '[' token
+	Left  Expression  // the expression being indexed
+	Index Expression
+}
+
+func (ie *IndexExpression) expressionNode()      {}
+func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) String() string {
+	var out bytes.Buffer
+
+	// parentheses added so grouping is more obvious
+	out.WriteString("(")
+	out.WriteString(ie.Left.String())
+	out.WriteString("[")
+	out.WriteString(ie.Index.String())
+	out.WriteString("])")
+
+	return out.String()
+}
+
+type HashLiteral struct {
+	Token token.Token // the '{' token
+	// a slice rather than a map so the pairs keep the order they were written in
+	Pairs []*HashPair
+}
+
+// HashPair is a single key: value entry of a HashLiteral
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
+func (hl *HashLiteral) expressionNode()      {}
+func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+func (hl *HashLiteral) String() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range hl.Pairs {
+		pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode()      {}
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
+
+// BadStatement stands in for a statement the parser could not make sense of,
+// From and To span the source it skipped over to get to the next statement
+type BadStatement struct {
+	Token token.Token // the first token of the statement
+	From  token.Position
+	To    token.Position
+}
+
+func (bs *BadStatement) statementNode()       {}
+func (bs *BadStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BadStatement) String() string       { return "<bad statement>" }
+
+// BadExpression stands in for an expression with a syntax error in it,
+// so the nodes around it can still be built
+type BadExpression struct {
+	Token token.Token // the first token of the expression
+	From  token.Position
+	To    token.Position
+}
+
+func (be *BadExpression) expressionNode()      {}
+func (be *BadExpression) TokenLiteral() string { return be.Token.Literal }
+func (be *BadExpression) String() string       { return "<bad expression>" }
@@ -0,0 +1,589 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/token"
+)
+
+// jsonNode is the JSON form of every kind of node. kind is the node's type name
+// without the package, only the fields that kind has are written out.
+// lists are pointers so an empty list is kept while a missing one is left out
+type jsonNode struct {
+	Kind  string          `json:"kind"`
+	Token *token.Token    `json:"token,omitempty"` // every node has one except Program
+	From  *token.Position `json:"from,omitempty"`
+	To    *token.Position `json:"to,omitempty"`
+	// the closing '}' of a block
+	Rbrace *token.Position `json:"rbrace,omitempty"`
+
+	Name        *jsonNode    `json:"name,omitempty"`
+	Parameters  *[]*jsonNode `json:"parameters,omitempty"`
+	Function    *jsonNode    `json:"function,omitempty"`
+	Left        *jsonNode    `json:"left,omitempty"`
+	Operator    string       `json:"operator,omitempty"`
+	Right       *jsonNode    `json:"right,omitempty"`
+	Index       *jsonNode    `json:"index,omitempty"`
+	Condition   *jsonNode    `json:"condition,omitempty"`
+	Consequence *jsonNode    `json:"consequence,omitempty"`
+	Alternative *jsonNode    `json:"alternative,omitempty"`
+	Arguments   *[]*jsonNode `json:"arguments,omitempty"`
+	Elements    *[]*jsonNode `json:"elements,omitempty"`
+	Pairs       *[]jsonPair  `json:"pairs,omitempty"`
+	Statements  *[]*jsonNode `json:"statements,omitempty"`
+	Body        *jsonNode    `json:"body,omitempty"`
+	// the expression of an expression, let or return statement
+	Expression *jsonNode `json:"expression,omitempty"`
+	// a literal's value
+	Value    json.RawMessage `json:"value,omitempty"`
+	Comments []*jsonNode     `json:"comments,omitempty"`
+}
+
+type jsonPair struct {
+	Key   *jsonNode `json:"key"`
+	Value *jsonNode `json:"value"`
+}
+
+// MarshalNode returns the JSON encoding of the tree rooted at node.
+// every node is an object holding its kind, its token with the token's
+// position and its children, the same tree comes back from UnmarshalNode
+func MarshalNode(node Node) ([]byte, error) {
+	n, err := encodeNode(node)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(n)
+}
+
+// UnmarshalNode builds the tree MarshalNode encoded in data. lists come back
+// empty rather than nil, as the parser leaves them
+func UnmarshalNode(data []byte) (Node, error) {
+	var n jsonNode
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil, err
+	}
+	return decodeNode(&n)
+}
+
+// MarshalJSON makes a Program a json.Marshaler, see MarshalNode
+func (p *Program) MarshalJSON() ([]byte, error) {
+	return MarshalNode(p)
+}
+
+// UnmarshalJSON makes a Program a json.Unmarshaler, see UnmarshalNode
+func (p *Program) UnmarshalJSON(data []byte) error {
+	node, err := UnmarshalNode(data)
+	if err != nil {
+		return err
+	}
+	program, ok := node.(*Program)
+	if !ok {
+		return fmt.Errorf("ast: expected a Program, got %s", kindOf(node))
+	}
+	*p = *program
+	return nil
+}
+
+// the import path of this package, only its node types have a kind
+var astPkgPath = reflect.TypeOf(Program{}).PkgPath()
+
+// kindOf names a node in the JSON, *ast.LetStatement is "LetStatement".
+// it is "" for anything but a pointer to a type of this package, which
+// encodeNode refuses anyway
+func kindOf(node Node) string {
+	t := reflect.TypeOf(node)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().PkgPath() != astPkgPath {
+		return ""
+	}
+	return t.Elem().Name()
+}
+
+func encodeNode(node Node) (*jsonNode, error) {
+	n := &jsonNode{Kind: kindOf(node)}
+	var err error
+
+	switch node := node.(type) {
+	case *Program:
+		n.Statements, err = encodeStatements(node.Statements)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range node.Comments {
+			n.Comments = append(n.Comments, &jsonNode{Kind: kindOf(c), Token: tokenOf(c.Token)})
+		}
+
+	case *Comment:
+		n.Token = tokenOf(node.Token)
+
+	case *LetStatement:
+		n.Token = tokenOf(node.Token)
+		if n.Name, err = encodeChild(node.Name); err != nil {
+			return nil, err
+		}
+		if node.Value != nil {
+			n.Expression, err = encodeNode(node.Value)
+		}
+
+	case *ReturnStatement:
+		n.Token = tokenOf(node.Token)
+		if node.ReturnValue != nil {
+			n.Expression, err = encodeNode(node.ReturnValue)
+		}
+
+	case *ExpressionStatement:
+		n.Token = tokenOf(node.Token)
+		if node.Expression != nil {
+			n.Expression, err = encodeNode(node.Expression)
+		}
+
+	case *BlockStatement:
+		n.Token = tokenOf(node.Token)
+		if n.Statements, err = encodeStatements(node.Statements); err != nil {
+			return nil, err
+		}
+		if node.Rbrace.IsValid() {
+			n.Rbrace = &node.Rbrace
+		}
+
+	case *BadStatement:
+		n.Token = tokenOf(node.Token)
+		n.From, n.To = &node.From, &node.To
+
+	case *Identifier:
+		n.Token = tokenOf(node.Token)
+		n.Value, err = json.Marshal(node.Value)
+
+	case *IntegerLiteral:
+		n.Token = tokenOf(node.Token)
+		// written as a JSON number with every digit, even past the range of an int64
+		if node.Big != nil {
+			n.Value = json.RawMessage(node.Big.String())
+		} else {
+			n.Value = json.RawMessage(strconv.FormatInt(node.Value, 10))
+		}
+
+	case *FloatLiteral:
+		n.Token = tokenOf(node.Token)
+		n.Value, err = json.Marshal(node.Value)
+
+	case *StringLiteral:
+		n.Token = tokenOf(node.Token)
+		n.Value, err = json.Marshal(node.Value)
+
+	case *Boolean:
+		n.Token = tokenOf(node.Token)
+		n.Value, err = json.Marshal(node.Value)
+
+	case *PrefixExpression:
+		n.Token = tokenOf(node.Token)
+		n.Operator = node.Operator
+		n.Right, err = encodeChild(node.Right)
+
+	case *InfixExpression:
+		n.Token = tokenOf(node.Token)
+		n.Operator = node.Operator
+		if n.Left, err = encodeChild(node.Left); err != nil {
+			return nil, err
+		}
+		n.Right, err = encodeChild(node.Right)
+
+	case *IfExpression:
+		n.Token = tokenOf(node.Token)
+		if n.Condition, err = encodeChild(node.Condition); err != nil {
+			return nil, err
+		}
+		if n.Consequence, err = encodeChild(node.Consequence); err != nil {
+			return nil, err
+		}
+		if node.Alternative != nil {
+			n.Alternative, err = encodeNode(node.Alternative)
+		}
+
+	case *FunctionLiteral:
+		n.Token = tokenOf(node.Token)
+		params := make([]*jsonNode, len(node.Parameters))
+		for i, param := range node.Parameters {
+			if params[i], err = encodeChild(param); err != nil {
+				return nil, err
+			}
+		}
+		n.Parameters = &params
+		n.Body, err = encodeChild(node.Body)
+
+	case *CallExpression:
+		n.Token = tokenOf(node.Token)
+		if n.Function, err = encodeChild(node.Function); err != nil {
+			return nil, err
+		}
+		n.Arguments, err = encodeExpressions(node.Arguments)
+
+	case *ArrayLiteral:
+		n.Token = tokenOf(node.Token)
+		n.Elements, err = encodeExpressions(node.Elements)
+
+	case *IndexExpression:
+		n.Token = tokenOf(node.Token)
+		if n.Left, err = encodeChild(node.Left); err != nil {
+			return nil, err
+		}
+		n.Index, err = encodeChild(node.Index)
+
+	case *HashLiteral:
+		n.Token = tokenOf(node.Token)
+		pairs := make([]jsonPair, len(node.Pairs))
+		for i, pair := range node.Pairs {
+			if pairs[i].Key, err = encodeChild(pair.Key); err != nil {
+				return nil, err
+			}
+			if pairs[i].Value, err = encodeChild(pair.Value); err != nil {
+				return nil, err
+			}
+		}
+		n.Pairs = &pairs
+
+	case *BadExpression:
+		n.Token = tokenOf(node.Token)
+		n.From, n.To = &node.From, &node.To
+
+	default:
+		return nil, fmt.Errorf("ast: can't encode node of type %T", node)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func tokenOf(tok token.Token) *token.Token { return &tok }
+
+// a child the node can't do without, a missing one is an error rather than
+// being left out and making the encoding impossible to decode
+func encodeChild(node Node) (*jsonNode, error) {
+	switch node := node.(type) {
+	case nil:
+		return nil, fmt.Errorf("ast: missing child node")
+	case *Identifier:
+		if node == nil {
+			return nil, fmt.Errorf("ast: missing identifier")
+		}
+	case *BlockStatement:
+		if node == nil {
+			return nil, fmt.Errorf("ast: missing block")
+		}
+	}
+	return encodeNode(node)
+}
+
+func encodeStatements(list []Statement) (*[]*jsonNode, error) {
+	nodes := make([]*jsonNode, len(list))
+	for i, stmt := range list {
+		n, err := encodeChild(stmt)
+		if err != nil {
+			return nil, err
+		}
+		nodes[i] = n
+	}
+	return &nodes, nil
+}
+
+func encodeExpressions(list []Expression) (*[]*jsonNode, error) {
+	nodes := make([]*jsonNode, len(list))
+	for i, exp := range list {
+		n, err := encodeChild(exp)
+		if err != nil {
+			return nil, err
+		}
+		nodes[i] = n
+	}
+	return &nodes, nil
+}
+
+func decodeNode(n *jsonNode) (Node, error) {
+	var tok token.Token
+	if n.Token != nil {
+		tok = *n.Token
+	}
+	var err error
+
+	switch n.Kind {
+	case "Program":
+		program := &Program{}
+		if program.Statements, err = decodeStatements(n.Statements); err != nil {
+			return nil, err
+		}
+		for _, c := range n.Comments {
+			comment, err := decodeNode(c)
+			if err != nil {
+				return nil, err
+			}
+			cm, ok := comment.(*Comment)
+			if !ok {
+				return nil, fmt.Errorf("ast: expected a Comment, got %s", c.Kind)
+			}
+			program.Comments = append(program.Comments, cm)
+		}
+		return program, nil
+
+	case "Comment":
+		return &Comment{Token: tok}, nil
+
+	case "LetStatement":
+		stmt := &LetStatement{Token: tok}
+		if stmt.Name, err = decodeIdentifier(n.Name); err != nil {
+			return nil, err
+		}
+		if n.Expression != nil {
+			stmt.Value, err = decodeExpression(n.Expression)
+		}
+		return stmt, err
+
+	case "ReturnStatement":
+		stmt := &ReturnStatement{Token: tok}
+		if n.Expression != nil {
+			stmt.ReturnValue, err = decodeExpression(n.Expression)
+		}
+		return stmt, err
+
+	case "ExpressionStatement":
+		stmt := &ExpressionStatement{Token: tok}
+		if n.Expression != nil {
+			stmt.Expression, err = decodeExpression(n.Expression)
+		}
+		return stmt, err
+
+	case "BlockStatement":
+		block := &BlockStatement{Token: tok}
+		if n.Rbrace != nil {
+			block.Rbrace = *n.Rbrace
+		}
+		block.Statements, err = decodeStatements(n.Statements)
+		return block, err
+
+	case "BadStatement":
+		stmt := &BadStatement{Token: tok}
+		stmt.From, stmt.To = decodeSpan(n)
+		return stmt, nil
+
+	case "Identifier":
+		ident := &Identifier{Token: tok}
+		return ident, decodeValue(n, &ident.Value)
+
+	case "IntegerLiteral":
+		lit := &IntegerLiteral{Token: tok}
+		var num json.Number
+		if err := decodeValue(n, &num); err != nil {
+			return nil, err
+		}
+		value, ok := new(big.Int).SetString(string(num), 10)
+		if !ok {
+			return nil, fmt.Errorf("ast: invalid integer value %s", num)
+		}
+		if value.IsInt64() {
+			lit.Value = value.Int64()
+		} else {
+			lit.Big = value
+		}
+		return lit, nil
+
+	case "FloatLiteral":
+		lit := &FloatLiteral{Token: tok}
+		return lit, decodeValue(n, &lit.Value)
+
+	case "StringLiteral":
+		lit := &StringLiteral{Token: tok}
+		return lit, decodeValue(n, &lit.Value)
+
+	case "Boolean":
+		lit := &Boolean{Token: tok}
+		return lit, decodeValue(n, &lit.Value)
+
+	case "PrefixExpression":
+		exp := &PrefixExpression{Token: tok, Operator: n.Operator}
+		exp.Right, err = decodeExpression(n.Right)
+		return exp, err
+
+	case "InfixExpression":
+		exp := &InfixExpression{Token: tok, Operator: n.Operator}
+		if exp.Left, err = decodeExpression(n.Left); err != nil {
+			return nil, err
+		}
+		exp.Right, err = decodeExpression(n.Right)
+		return exp, err
+
+	case "IfExpression":
+		exp := &IfExpression{Token: tok}
+		if exp.Condition, err = decodeExpression(n.Condition); err != nil {
+			return nil, err
+		}
+		if exp.Consequence, err = decodeBlock(n.Consequence); err != nil {
+			return nil, err
+		}
+		if n.Alternative != nil {
+			exp.Alternative, err = decodeBlock(n.Alternative)
+		}
+		return exp, err
+
+	case "FunctionLiteral":
+		fn := &FunctionLiteral{Token: tok, Parameters: []*Identifier{}}
+		if n.Parameters != nil {
+			for _, p := range *n.Parameters {
+				param, err := decodeIdentifier(p)
+				if err != nil {
+					return nil, err
+				}
+				fn.Parameters = append(fn.Parameters, param)
+			}
+		}
+		fn.Body, err = decodeBlock(n.Body)
+		return fn, err
+
+	case "CallExpression":
+		exp := &CallExpression{Token: tok}
+		if exp.Function, err = decodeExpression(n.Function); err != nil {
+			return nil, err
+		}
+		exp.Arguments, err = decodeExpressions(n.Arguments)
+		return exp, err
+
+	case "ArrayLiteral":
+		array := &ArrayLiteral{Token: tok}
+		array.Elements, err = decodeExpressions(n.Elements)
+		return array, err
+
+	case "IndexExpression":
+		exp := &IndexExpression{Token: tok}
+		if exp.Left, err = decodeExpression(n.Left); err != nil {
+			return nil, err
+		}
+		exp.Index, err = decodeExpression(n.Index)
+		return exp, err
+
+	case "HashLiteral":
+		hash := &HashLiteral{Token: tok, Pairs: []*HashPair{}}
+		if n.Pairs != nil {
+			for _, p := range *n.Pairs {
+				pair := &HashPair{}
+				if pair.Key, err = decodeExpression(p.Key); err != nil {
+					return nil, err
+				}
+				if pair.Value, err = decodeExpression(p.Value); err != nil {
+					return nil, err
+				}
+				hash.Pairs = append(hash.Pairs, pair)
+			}
+		}
+		return hash, nil
+
+	case "BadExpression":
+		exp := &BadExpression{Token: tok}
+		exp.From, exp.To = decodeSpan(n)
+		return exp, nil
+
+	default:
+		return nil, fmt.Errorf("ast: unknown node kind %q", n.Kind)
+	}
+}
+
+func decodeValue(n *jsonNode, v interface{}) error {
+	if len(n.Value) == 0 {
+		return fmt.Errorf("ast: %s has no value", n.Kind)
+	}
+	return json.Unmarshal(n.Value, v)
+}
+
+func decodeSpan(n *jsonNode) (from, to token.Position) {
+	if n.From != nil {
+		from = *n.From
+	}
+	if n.To != nil {
+		to = *n.To
+	}
+	return from, to
+}
+
+func decodeExpression(n *jsonNode) (Expression, error) {
+	if n == nil {
+		return nil, fmt.Errorf("ast: missing expression")
+	}
+	node, err := decodeNode(n)
+	if err != nil {
+		return nil, err
+	}
+	exp, ok := node.(Expression)
+	if !ok {
+		return nil, fmt.Errorf("ast: expected an expression, got %s", n.Kind)
+	}
+	return exp, nil
+}
+
+func decodeBlock(n *jsonNode) (*BlockStatement, error) {
+	if n == nil {
+		return nil, fmt.Errorf("ast: missing block")
+	}
+	node, err := decodeNode(n)
+	if err != nil {
+		return nil, err
+	}
+	block, ok := node.(*BlockStatement)
+	if !ok {
+		return nil, fmt.Errorf("ast: expected a BlockStatement, got %s", n.Kind)
+	}
+	return block, nil
+}
+
+func decodeIdentifier(n *jsonNode) (*Identifier, error) {
+	if n == nil {
+		return nil, fmt.Errorf("ast: missing identifier")
+	}
+	node, err := decodeNode(n)
+	if err != nil {
+		return nil, err
+	}
+	ident, ok := node.(*Identifier)
+	if !ok {
+		return nil, fmt.Errorf("ast: expected an Identifier, got %s", n.Kind)
+	}
+	return ident, nil
+}
+
+func decodeStatements(list *[]*jsonNode) ([]Statement, error) {
+	stmts := []Statement{}
+	if list == nil {
+		return stmts, nil
+	}
+	for _, n := range *list {
+		if n == nil {
+			return nil, fmt.Errorf("ast: missing statement")
+		}
+		node, err := decodeNode(n)
+		if err != nil {
+			return nil, err
+		}
+		stmt, ok := node.(Statement)
+		if !ok {
+			return nil, fmt.Errorf("ast: expected a statement, got %s", n.Kind)
+		}
+		stmts = append(stmts, stmt)
+	}
+	return stmts, nil
+}
+
+func decodeExpressions(list *[]*jsonNode) ([]Expression, error) {
+	exps := []Expression{}
+	if list == nil {
+		return exps, nil
+	}
+	for _, n := range *list {
+		exp, err := decodeExpression(n)
+		if err != nil {
+			return nil, err
+		}
+		exps = append(exps, exp)
+	}
+	return exps, nil
+}
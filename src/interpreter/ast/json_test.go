@@ -0,0 +1,232 @@
+package ast_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/ast"
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/lexer"
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/parser"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	tests := []string{
+		"let x = 5; return x; x;",
+		"-a * b ** 2 % c <= d && !e || f >= 1.5",
+		`let add = fn(a, b) { return a + b; }; add(1, 2)(3)`,
+		`if (x < y) { x } else if (x > y) { y } else { "same\n" }`,
+		`fn() {}; [1, "two", [3]][0]; {}; {"k": true, 2: false}["k"]`,
+		"99999999999999999999999999 + 0x10",
+		"let = 1; f(1, ); return;",
+	}
+
+	for _, input := range tests {
+		program := parser.New(lexer.New(input, lexer.WithFilename("test.chl"), lexer.WithComments())).ParseProgram()
+
+		data, err := json.Marshal(program)
+		if err != nil {
+			t.Fatalf("json.Marshal(%q) returned error: %s", input, err)
+		}
+
+		decoded := &ast.Program{}
+		if err := json.Unmarshal(data, decoded); err != nil {
+			t.Fatalf("json.Unmarshal for %q returned error: %s\n%s", input, err, data)
+		}
+
+		if !reflect.DeepEqual(decoded, program) {
+			t.Errorf("round trip of %q changed the tree.\nexpected=%q\ngot=     %q", input, program.String(), decoded.String())
+		}
+	}
+}
+
+func TestJSONKeepsComments(t *testing.T) {
+	input := "// one\nlet x = 1; /* two */"
+	program := parser.New(lexer.New(input, lexer.WithComments())).ParseProgram()
+
+	data, err := json.Marshal(program)
+	if err != nil {
+		t.Fatalf("json.Marshal returned error: %s", err)
+	}
+
+	decoded := &ast.Program{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("json.Unmarshal returned error: %s", err)
+	}
+
+	if len(decoded.Comments) != 2 {
+		t.Fatalf("wrong number of comments. expected=2, got=%d", len(decoded.Comments))
+	}
+	if !reflect.DeepEqual(decoded, program) {
+		t.Errorf("round trip changed the comments. got=%v", decoded.Comments)
+	}
+}
+
+// the schema is what other tools read, so changes to it should show up here
+func TestJSONSchema(t *testing.T) {
+	program := parser.New(lexer.New("let x = -1;")).ParseProgram()
+
+	data, err := json.MarshalIndent(program, "", "  ")
+	if err != nil {
+		t.Fatalf("json.MarshalIndent returned error: %s", err)
+	}
+
+	expected := `{
+  "kind": "Program",
+  "statements": [
+    {
+      "kind": "LetStatement",
+      "token": {
+        "type": "LET",
+        "literal": "let",
+        "pos": {
+          "offset": 0,
+          "line": 1,
+          "column": 1
+        },
+        "end": {
+          "offset": 3,
+          "line": 1,
+          "column": 4
+        }
+      },
+      "name": {
+        "kind": "Identifier",
+        "token": {
+          "type": "IDENT",
+          "literal": "x",
+          "pos": {
+            "offset": 4,
+            "line": 1,
+            "column": 5
+          },
+          "end": {
+            "offset": 5,
+            "line": 1,
+            "column": 6
+          }
+        },
+        "value": "x"
+      },
+      "expression": {
+        "kind": "PrefixExpression",
+        "token": {
+          "type": "-",
+          "literal": "-",
+          "pos": {
+            "offset": 8,
+            "line": 1,
+            "column": 9
+          },
+          "end": {
+            "offset": 9,
+            "line": 1,
+            "column": 10
+          }
+        },
+        "operator": "-",
+        "right": {
+          "kind": "IntegerLiteral",
+          "token": {
+            "type": "INT",
+            "literal": "1",
+            "pos": {
+              "offset": 9,
+              "line": 1,
+              "column": 10
+            },
+            "end": {
+              "offset": 10,
+              "line": 1,
+              "column": 11
+            }
+          },
+          "value": 1
+        }
+      }
+    }
+  ]
+}`
+
+	if string(data) != expected {
+		t.Errorf("JSON wrong.\nexpected=\n%s\ngot=\n%s", expected, data)
+	}
+}
+
+func TestMarshalNode(t *testing.T) {
+	exp := &ast.InfixExpression{
+		Left:     &ast.IntegerLiteral{Value: 1},
+		Operator: "+",
+		Right:    &ast.Identifier{Value: "x"},
+	}
+
+	data, err := ast.MarshalNode(exp)
+	if err != nil {
+		t.Fatalf("MarshalNode returned error: %s", err)
+	}
+
+	node, err := ast.UnmarshalNode(data)
+	if err != nil {
+		t.Fatalf("UnmarshalNode returned error: %s", err)
+	}
+	if !reflect.DeepEqual(node, exp) {
+		t.Errorf("round trip changed the node. expected=%#v, got=%#v", exp, node)
+	}
+}
+
+// a node from outside package ast
+type otherNode struct{}
+
+func (otherNode) TokenLiteral() string { return "" }
+func (otherNode) String() string       { return "" }
+
+func TestMarshalNodeErrors(t *testing.T) {
+	tests := []struct {
+		node     ast.Node
+		expected string
+	}{
+		{&ast.PrefixExpression{Operator: "-"}, "ast: missing child node"},
+		{&ast.LetStatement{Value: &ast.Boolean{}}, "ast: missing identifier"},
+		{&ast.FunctionLiteral{}, "ast: missing block"},
+		// only the package's own nodes have a kind in the schema
+		{&otherNode{}, "ast: can't encode node of type *ast_test.otherNode"},
+		{otherNode{}, "ast: can't encode node of type ast_test.otherNode"},
+	}
+
+	for _, tt := range tests {
+		_, err := ast.MarshalNode(tt.node)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("wrong error for %T. expected=%q, got=%v", tt.node, tt.expected, err)
+		}
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"kind": "Nope"}`, `ast: unknown node kind "Nope"`},
+		{`{"kind": "Program", "statements": [{"kind": "Boolean", "value": true}]}`,
+			"ast: expected a statement, got Boolean"},
+		{`{"kind": "PrefixExpression", "operator": "-", "right": {"kind": "BlockStatement"}}`,
+			"ast: expected an expression, got BlockStatement"},
+		{`{"kind": "InfixExpression", "left": {"kind": "Boolean", "value": true}}`, "ast: missing expression"},
+		{`{"kind": "Identifier"}`, "ast: Identifier has no value"},
+		{`{"kind": "IntegerLiteral", "value": 1.5}`, "ast: invalid integer value 1.5"},
+	}
+
+	for _, tt := range tests {
+		_, err := ast.UnmarshalNode([]byte(tt.input))
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("wrong error for %s. expected=%q, got=%v", tt.input, tt.expected, err)
+		}
+	}
+
+	// a Program can only be loaded from a program
+	err := json.Unmarshal([]byte(`{"kind": "Boolean", "value": true}`), &ast.Program{})
+	if err == nil || !strings.Contains(err.Error(), "expected a Program, got Boolean") {
+		t.Errorf("wrong error unmarshaling a Boolean into a Program. got=%v", err)
+	}
+}
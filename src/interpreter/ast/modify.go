@@ -0,0 +1,137 @@
+package ast
+
+import "fmt"
+
+// ModifierFunc is called by Modify with every node in a tree and returns the
+// node to put in its place, which may be the node it was given
+type ModifierFunc func(Node) Node
+
+// Modify rewrites the tree rooted at node bottom up: the children of a node are
+// modified before the node itself is passed to modifier, and the result of
+// modifier on node is returned. nodes are changed in place, so copy the tree
+// first if the original is still needed.
+//
+// a replacement has to fit where the node it replaces was, an Expression for
+// an Expression and so on, Modify panics if it doesn't. returning nil for a
+// statement in a Program or block removes it
+func Modify(node Node, modifier ModifierFunc) Node {
+	switch node := node.(type) {
+
+	// Statements
+	case *Program:
+		node.Statements = modifyStatements(node.Statements, modifier)
+
+	case *LetStatement:
+		node.Name = modifyIdentifier(node.Name, modifier)
+		node.Value = modifyExpression(node.Value, modifier)
+
+	case *ReturnStatement:
+		node.ReturnValue = modifyExpression(node.ReturnValue, modifier)
+
+	case *ExpressionStatement:
+		node.Expression = modifyExpression(node.Expression, modifier)
+
+	case *BlockStatement:
+		node.Statements = modifyStatements(node.Statements, modifier)
+
+	// Expressions
+	case *PrefixExpression:
+		node.Right = modifyExpression(node.Right, modifier)
+
+	case *InfixExpression:
+		node.Left = modifyExpression(node.Left, modifier)
+		node.Right = modifyExpression(node.Right, modifier)
+
+	case *IfExpression:
+		node.Condition = modifyExpression(node.Condition, modifier)
+		node.Consequence = modifyBlock(node.Consequence, modifier)
+		node.Alternative = modifyBlock(node.Alternative, modifier)
+
+	case *FunctionLiteral:
+		for i, param := range node.Parameters {
+			node.Parameters[i] = modifyIdentifier(param, modifier)
+		}
+		node.Body = modifyBlock(node.Body, modifier)
+
+	case *CallExpression:
+		node.Function = modifyExpression(node.Function, modifier)
+		node.Arguments = modifyExpressions(node.Arguments, modifier)
+
+	case *ArrayLiteral:
+		node.Elements = modifyExpressions(node.Elements, modifier)
+
+	case *IndexExpression:
+		node.Left = modifyExpression(node.Left, modifier)
+		node.Index = modifyExpression(node.Index, modifier)
+
+	case *HashLiteral:
+		for _, pair := range node.Pairs {
+			pair.Key = modifyExpression(pair.Key, modifier)
+			pair.Value = modifyExpression(pair.Value, modifier)
+		}
+	}
+
+	// leaves have no children, they only go through modifier itself
+	return modifier(node)
+}
+
+// a nil list entry left by the modifier is dropped
+func modifyStatements(list []Statement, modifier ModifierFunc) []Statement {
+	modified := list[:0]
+	for _, stmt := range list {
+		result := Modify(stmt, modifier)
+		if result == nil {
+			continue
+		}
+		s, ok := result.(Statement)
+		if !ok {
+			panic(fmt.Sprintf("ast.Modify: %T can't replace statement %T", result, stmt))
+		}
+		modified = append(modified, s)
+	}
+	return modified
+}
+
+func modifyExpressions(list []Expression, modifier ModifierFunc) []Expression {
+	for i, exp := range list {
+		list[i] = modifyExpression(exp, modifier)
+	}
+	return list
+}
+
+// optional children that are missing stay missing
+func modifyExpression(exp Expression, modifier ModifierFunc) Expression {
+	if exp == nil {
+		return nil
+	}
+	result := Modify(exp, modifier)
+	e, ok := result.(Expression)
+	if !ok {
+		panic(fmt.Sprintf("ast.Modify: %T can't replace expression %T", result, exp))
+	}
+	return e
+}
+
+func modifyBlock(block *BlockStatement, modifier ModifierFunc) *BlockStatement {
+	if block == nil {
+		return nil
+	}
+	result := Modify(block, modifier)
+	b, ok := result.(*BlockStatement)
+	if !ok {
+		panic(fmt.Sprintf("ast.Modify: %T can't replace a *ast.BlockStatement", result))
+	}
+	return b
+}
+
+func modifyIdentifier(ident *Identifier, modifier ModifierFunc) *Identifier {
+	if ident == nil {
+		return nil
+	}
+	result := Modify(ident, modifier)
+	i, ok := result.(*Identifier)
+	if !ok {
+		panic(fmt.Sprintf("ast.Modify: %T can't replace a *ast.Identifier", result))
+	}
+	return i
+}
@@ -0,0 +1,219 @@
+package ast
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestModify(t *testing.T) {
+	one := func() Expression { return &IntegerLiteral{Value: 1} }
+	two := func() Expression { return &IntegerLiteral{Value: 2} }
+
+	turnOneIntoTwo := func(node Node) Node {
+		integer, ok := node.(*IntegerLiteral)
+		if !ok {
+			return node
+		}
+
+		if integer.Value != 1 {
+			return node
+		}
+
+		integer.Value = 2
+		return integer
+	}
+
+	tests := []struct {
+		input    Node
+		expected Node
+	}{
+		{
+			one(),
+			two(),
+		},
+		{
+			&Program{
+				Statements: []Statement{
+					&ExpressionStatement{Expression: one()},
+				},
+			},
+			&Program{
+				Statements: []Statement{
+					&ExpressionStatement{Expression: two()},
+				},
+			},
+		},
+		{
+			&InfixExpression{Left: one(), Operator: "+", Right: two()},
+			&InfixExpression{Left: two(), Operator: "+", Right: two()},
+		},
+		{
+			&InfixExpression{Left: two(), Operator: "+", Right: one()},
+			&InfixExpression{Left: two(), Operator: "+", Right: two()},
+		},
+		{
+			&PrefixExpression{Operator: "-", Right: one()},
+			&PrefixExpression{Operator: "-", Right: two()},
+		},
+		{
+			&IndexExpression{Left: one(), Index: one()},
+			&IndexExpression{Left: two(), Index: two()},
+		},
+		{
+			&IfExpression{
+				Condition: one(),
+				Consequence: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: one()},
+					},
+				},
+				Alternative: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: one()},
+					},
+				},
+			},
+			&IfExpression{
+				Condition: two(),
+				Consequence: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: two()},
+					},
+				},
+				Alternative: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: two()},
+					},
+				},
+			},
+		},
+		{
+			&ReturnStatement{ReturnValue: one()},
+			&ReturnStatement{ReturnValue: two()},
+		},
+		{
+			&LetStatement{Name: &Identifier{Value: "x"}, Value: one()},
+			&LetStatement{Name: &Identifier{Value: "x"}, Value: two()},
+		},
+		{
+			&FunctionLiteral{
+				Parameters: []*Identifier{},
+				Body: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: one()},
+					},
+				},
+			},
+			&FunctionLiteral{
+				Parameters: []*Identifier{},
+				Body: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: two()},
+					},
+				},
+			},
+		},
+		{
+			&CallExpression{Function: &Identifier{Value: "f"}, Arguments: []Expression{one(), two()}},
+			&CallExpression{Function: &Identifier{Value: "f"}, Arguments: []Expression{two(), two()}},
+		},
+		{
+			&ArrayLiteral{Elements: []Expression{one(), one()}},
+			&ArrayLiteral{Elements: []Expression{two(), two()}},
+		},
+		{
+			&HashLiteral{Pairs: []*HashPair{
+				{Key: one(), Value: one()},
+				{Key: two(), Value: one()},
+			}},
+			&HashLiteral{Pairs: []*HashPair{
+				{Key: two(), Value: two()},
+				{Key: two(), Value: two()},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		modified := Modify(tt.input, turnOneIntoTwo)
+
+		if !reflect.DeepEqual(modified, tt.expected) {
+			t.Errorf("not equal. got=%#v, want=%#v", modified, tt.expected)
+		}
+	}
+}
+
+// children are modified before their parent, so a parent sees rewritten children
+func TestModifyIsPostOrder(t *testing.T) {
+	// (1 + 2) * 3 folded into 9
+	input := &InfixExpression{
+		Left:     &InfixExpression{Left: &IntegerLiteral{Value: 1}, Operator: "+", Right: &IntegerLiteral{Value: 2}},
+		Operator: "*",
+		Right:    &IntegerLiteral{Value: 3},
+	}
+
+	fold := func(node Node) Node {
+		infix, ok := node.(*InfixExpression)
+		if !ok {
+			return node
+		}
+		left, leftOk := infix.Left.(*IntegerLiteral)
+		right, rightOk := infix.Right.(*IntegerLiteral)
+		if !leftOk || !rightOk {
+			return node
+		}
+
+		switch infix.Operator {
+		case "+":
+			return &IntegerLiteral{Value: left.Value + right.Value}
+		case "*":
+			return &IntegerLiteral{Value: left.Value * right.Value}
+		}
+		return node
+	}
+
+	modified, ok := Modify(input, fold).(*IntegerLiteral)
+	if !ok || modified.Value != 9 {
+		t.Errorf("expression not folded to 9. got=%#v", modified)
+	}
+}
+
+func TestModifyRemovesNilStatements(t *testing.T) {
+	program := &Program{
+		Statements: []Statement{
+			&ExpressionStatement{Expression: &IntegerLiteral{Value: 1}},
+			&ExpressionStatement{Expression: &Boolean{Value: true}},
+			&ExpressionStatement{Expression: &IntegerLiteral{Value: 2}},
+		},
+	}
+
+	dropBooleans := func(node Node) Node {
+		if stmt, ok := node.(*ExpressionStatement); ok {
+			if _, ok := stmt.Expression.(*Boolean); ok {
+				return nil
+			}
+		}
+		return node
+	}
+
+	modified := Modify(program, dropBooleans).(*Program)
+
+	if len(modified.Statements) != 2 {
+		t.Fatalf("wrong number of statements. expected=2, got=%d", len(modified.Statements))
+	}
+}
+
+func TestModifyPanicsOnMisfit(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Modify did not panic on a statement replacing an expression")
+		}
+	}()
+
+	node := &PrefixExpression{Operator: "-", Right: &IntegerLiteral{Value: 1}}
+	Modify(node, func(node Node) Node {
+		if integer, ok := node.(*IntegerLiteral); ok {
+			return &ExpressionStatement{Expression: integer}
+		}
+		return node
+	})
+}
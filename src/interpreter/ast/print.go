@@ -0,0 +1,248 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/token"
+)
+
+// Fprint writes the tree rooted at node to w with one node per line, each
+// indented under its parent and named by the field of the parent holding it:
+//
+//	Program
+//	.  statements[0]: ExpressionStatement 1:1
+//	.  .  expression: InfixExpression "*" 1:4
+//	.  .  .  left: PrefixExpression "-" 1:1
+//	.  .  .  .  right: Identifier a 1:2
+//	.  .  .  right: Identifier b 1:6
+func Fprint(w io.Writer, node Node) error {
+	var out bytes.Buffer
+	fprint(&out, "", node, 0)
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+func fprint(out *bytes.Buffer, name string, node Node, depth int) {
+	out.WriteString(strings.Repeat(".  ", depth))
+	if name != "" {
+		out.WriteString(name + ": ")
+	}
+	out.WriteString(describe(node))
+	switch n := node.(type) {
+	case *BadStatement:
+		out.WriteString(" " + n.From.String() + "-" + n.To.String())
+	case *BadExpression:
+		out.WriteString(" " + n.From.String() + "-" + n.To.String())
+	default:
+		if pos := posOf(node); pos.IsValid() {
+			out.WriteString(" " + pos.String())
+		}
+	}
+	out.WriteByte('\n')
+
+	for _, f := range fields(node) {
+		fprint(out, f.name, f.node, depth+1)
+	}
+}
+
+// FprintDOT writes the tree rooted at node to w as a Graphviz DOT graph, with
+// an edge from every node to each of its children labelled by the field holding it
+func FprintDOT(w io.Writer, node Node) error {
+	var out bytes.Buffer
+	out.WriteString("digraph ast {\n")
+	out.WriteString("\tnode [shape=box, fontname=\"monospace\"];\n")
+
+	id := 0
+	var add func(node Node) int
+	add = func(node Node) int {
+		n := id
+		id += 1
+		fmt.Fprintf(&out, "\tn%d [label=%s];\n", n, dotQuote(describe(node)))
+		for _, f := range fields(node) {
+			child := add(f.node)
+			fmt.Fprintf(&out, "\tn%d -> n%d [label=%s];\n", n, child, dotQuote(f.name))
+		}
+		return n
+	}
+	add(node)
+
+	out.WriteString("}\n")
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// DOT strings escape nothing but '"', a '\' is doubled as well so it isn't
+// taken for the start of one of Graphviz's label escapes such as \n or \l
+var dotEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`)
+
+// dotQuote returns s as a double-quoted DOT string
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
+
+// the kind of node followed by what sets it apart from others of its kind
+func describe(node Node) string {
+	if node == nil {
+		return "nil"
+	}
+	kind := kindOf(node)
+	if kind == "" {
+		// a node from outside the package
+		kind = fmt.Sprintf("%T", node)
+	}
+	switch node := node.(type) {
+	case *Identifier:
+		return kind + " " + node.Value
+	case *IntegerLiteral:
+		return kind + " " + node.String()
+	case *FloatLiteral:
+		return kind + " " + node.String()
+	case *StringLiteral:
+		return kind + " " + Quote(node.Value)
+	case *Boolean:
+		return kind + " " + strconv.FormatBool(node.Value)
+	case *PrefixExpression:
+		return kind + " " + strconv.Quote(node.Operator)
+	case *InfixExpression:
+		return kind + " " + strconv.Quote(node.Operator)
+	case *Comment:
+		return kind + " " + strconv.Quote(node.Token.Literal)
+	}
+	return kind
+}
+
+// a child node and the name of the field of its parent holding it
+type field struct {
+	name string
+	node Node
+}
+
+// fields returns the children of node in the order Walk visits them,
+// children in a list are named by their index in it
+func fields(node Node) []field {
+	var fs []field
+	add := func(name string, child Node) {
+		fs = append(fs, field{name, child})
+	}
+	addStatements := func(name string, list []Statement) {
+		for i, stmt := range list {
+			add(fmt.Sprintf("%s[%d]", name, i), stmt)
+		}
+	}
+	addExpressions := func(name string, list []Expression) {
+		for i, exp := range list {
+			add(fmt.Sprintf("%s[%d]", name, i), exp)
+		}
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		addStatements("statements", n.Statements)
+
+	case *LetStatement:
+		add("name", n.Name)
+		if n.Value != nil {
+			add("value", n.Value)
+		}
+
+	case *ReturnStatement:
+		if n.ReturnValue != nil {
+			add("value", n.ReturnValue)
+		}
+
+	case *ExpressionStatement:
+		if n.Expression != nil {
+			add("expression", n.Expression)
+		}
+
+	case *BlockStatement:
+		addStatements("statements", n.Statements)
+
+	case *PrefixExpression:
+		add("right", n.Right)
+
+	case *InfixExpression:
+		add("left", n.Left)
+		add("right", n.Right)
+
+	case *IfExpression:
+		add("condition", n.Condition)
+		add("consequence", n.Consequence)
+		if n.Alternative != nil {
+			add("alternative", n.Alternative)
+		}
+
+	case *FunctionLiteral:
+		for i, param := range n.Parameters {
+			add(fmt.Sprintf("parameters[%d]", i), param)
+		}
+		add("body", n.Body)
+
+	case *CallExpression:
+		add("function", n.Function)
+		addExpressions("arguments", n.Arguments)
+
+	case *ArrayLiteral:
+		addExpressions("elements", n.Elements)
+
+	case *IndexExpression:
+		add("left", n.Left)
+		add("index", n.Index)
+
+	case *HashLiteral:
+		for i, pair := range n.Pairs {
+			add(fmt.Sprintf("keys[%d]", i), pair.Key)
+			add(fmt.Sprintf("values[%d]", i), pair.Value)
+		}
+	}
+
+	return fs
+}
+
+// position of the token a node starts from, Program has none and
+// bad nodes have a span instead
+func posOf(node Node) token.Position {
+	switch n := node.(type) {
+	case *Comment:
+		return n.Token.Pos
+	case *LetStatement:
+		return n.Token.Pos
+	case *ReturnStatement:
+		return n.Token.Pos
+	case *ExpressionStatement:
+		return n.Token.Pos
+	case *BlockStatement:
+		return n.Token.Pos
+	case *Identifier:
+		return n.Token.Pos
+	case *IntegerLiteral:
+		return n.Token.Pos
+	case *FloatLiteral:
+		return n.Token.Pos
+	case *StringLiteral:
+		return n.Token.Pos
+	case *Boolean:
+		return n.Token.Pos
+	case *PrefixExpression:
+		return n.Token.Pos
+	case *InfixExpression:
+		return n.Token.Pos
+	case *IfExpression:
+		return n.Token.Pos
+	case *FunctionLiteral:
+		return n.Token.Pos
+	case *CallExpression:
+		return n.Token.Pos
+	case *ArrayLiteral:
+		return n.Token.Pos
+	case *IndexExpression:
+		return n.Token.Pos
+	case *HashLiteral:
+		return n.Token.Pos
+	}
+	return token.Position{}
+}
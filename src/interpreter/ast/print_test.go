@@ -0,0 +1,134 @@
+package ast_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/ast"
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/lexer"
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/parser"
+)
+
+func TestFprint(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"-a * b",
+			`Program
+.  statements[0]: ExpressionStatement 1:1
+.  .  expression: InfixExpression "*" 1:4
+.  .  .  left: PrefixExpression "-" 1:1
+.  .  .  .  right: Identifier a 1:2
+.  .  .  right: Identifier b 1:6
+`,
+		},
+		{
+			// ** binds tighter than the prefix minus and is right associative
+			"-2 ** 3 ** 2",
+			`Program
+.  statements[0]: ExpressionStatement 1:1
+.  .  expression: PrefixExpression "-" 1:1
+.  .  .  right: InfixExpression "**" 1:4
+.  .  .  .  left: IntegerLiteral 2 1:2
+.  .  .  .  right: InfixExpression "**" 1:9
+.  .  .  .  .  left: IntegerLiteral 3 1:7
+.  .  .  .  .  right: IntegerLiteral 2 1:12
+`,
+		},
+		{
+			`let f = fn(x) { if (x) { f(x[0], {"k": true}) } else { 1.5 } };`,
+			`Program
+.  statements[0]: LetStatement 1:1
+.  .  name: Identifier f 1:5
+.  .  value: FunctionLiteral 1:9
+.  .  .  parameters[0]: Identifier x 1:12
+.  .  .  body: BlockStatement 1:15
+.  .  .  .  statements[0]: ExpressionStatement 1:17
+.  .  .  .  .  expression: IfExpression 1:17
+.  .  .  .  .  .  condition: Identifier x 1:21
+.  .  .  .  .  .  consequence: BlockStatement 1:24
+.  .  .  .  .  .  .  statements[0]: ExpressionStatement 1:26
+.  .  .  .  .  .  .  .  expression: CallExpression 1:27
+.  .  .  .  .  .  .  .  .  function: Identifier f 1:26
+.  .  .  .  .  .  .  .  .  arguments[0]: IndexExpression 1:29
+.  .  .  .  .  .  .  .  .  .  left: Identifier x 1:28
+.  .  .  .  .  .  .  .  .  .  index: IntegerLiteral 0 1:30
+.  .  .  .  .  .  .  .  .  arguments[1]: HashLiteral 1:34
+.  .  .  .  .  .  .  .  .  .  keys[0]: StringLiteral "k" 1:35
+.  .  .  .  .  .  .  .  .  .  values[0]: Boolean true 1:40
+.  .  .  .  .  .  alternative: BlockStatement 1:54
+.  .  .  .  .  .  .  statements[0]: ExpressionStatement 1:56
+.  .  .  .  .  .  .  .  expression: FloatLiteral 1.5 1:56
+`,
+		},
+		{
+			"let = 1; return [];",
+			`Program
+.  statements[0]: BadStatement 1:1-1:9
+.  statements[1]: ReturnStatement 1:10
+.  .  value: ArrayLiteral 1:17
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+
+		var out bytes.Buffer
+		if err := ast.Fprint(&out, program); err != nil {
+			t.Fatalf("Fprint returned error: %s", err)
+		}
+
+		if out.String() != tt.expected {
+			t.Errorf("tree for %q wrong.\nexpected=\n%s\ngot=\n%s", tt.input, tt.expected, out.String())
+		}
+	}
+}
+
+func TestFprintDOT(t *testing.T) {
+	program := parser.New(lexer.New(`-a * "b"`)).ParseProgram()
+
+	var out bytes.Buffer
+	if err := ast.FprintDOT(&out, program); err != nil {
+		t.Fatalf("FprintDOT returned error: %s", err)
+	}
+
+	expected := `digraph ast {
+	node [shape=box, fontname="monospace"];
+	n0 [label="Program"];
+	n1 [label="ExpressionStatement"];
+	n2 [label="InfixExpression \"*\""];
+	n3 [label="PrefixExpression \"-\""];
+	n4 [label="Identifier a"];
+	n3 -> n4 [label="right"];
+	n2 -> n3 [label="left"];
+	n5 [label="StringLiteral \"b\""];
+	n2 -> n5 [label="right"];
+	n1 -> n2 [label="expression"];
+	n0 -> n1 [label="statements[0]"];
+}
+`
+
+	if out.String() != expected {
+		t.Errorf("DOT wrong.\nexpected=\n%s\ngot=\n%s", expected, out.String())
+	}
+}
+
+// labels keep any text as it is, only '"' and '\' are escaped
+func TestFprintDOTEscapes(t *testing.T) {
+	// a tree built in code, the lexer has no identifiers like this one
+	ident := &ast.Identifier{Value: "a\u00a0\"b\"\\"}
+
+	var out bytes.Buffer
+	if err := ast.FprintDOT(&out, ident); err != nil {
+		t.Fatalf("FprintDOT returned error: %s", err)
+	}
+
+	expected := "n0 [label=\"Identifier a\u00a0\\\"b\\\"\\\\\"];"
+	if !strings.Contains(out.String(), expected) {
+		t.Errorf("DOT label wrong. expected to contain %q, got=\n%s", expected, out.String())
+	}
+}
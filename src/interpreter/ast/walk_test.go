@@ -0,0 +1,166 @@
+package ast_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/ast"
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/lexer"
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/parser"
+)
+
+// the parser imports ast, so these tests live in ast_test to use it
+
+func TestInspectCountsNodes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected map[string]int
+	}{
+		{
+			"let x = 1 + 2 * y;",
+			map[string]int{
+				"*ast.Program": 1, "*ast.LetStatement": 1, "*ast.Identifier": 2,
+				"*ast.InfixExpression": 2, "*ast.IntegerLiteral": 2,
+			},
+		},
+		{
+			"let add = fn(a, b) { return a + b; }; add(1, 2.5);",
+			map[string]int{
+				"*ast.Program": 1, "*ast.LetStatement": 1, "*ast.Identifier": 6,
+				"*ast.FunctionLiteral": 1, "*ast.BlockStatement": 1, "*ast.ReturnStatement": 1,
+				"*ast.InfixExpression": 1, "*ast.ExpressionStatement": 1, "*ast.CallExpression": 1,
+				"*ast.IntegerLiteral": 1, "*ast.FloatLiteral": 1,
+			},
+		},
+		{
+			`if (!ok) { "no" } else if (x) { [1, 2][0] } else { {"k": true}["k"] }`,
+			map[string]int{
+				"*ast.Program": 1, "*ast.ExpressionStatement": 5, "*ast.IfExpression": 2,
+				"*ast.PrefixExpression": 1, "*ast.Identifier": 2, "*ast.BlockStatement": 4,
+				"*ast.StringLiteral": 3, "*ast.IndexExpression": 2, "*ast.ArrayLiteral": 1,
+				"*ast.IntegerLiteral": 3, "*ast.HashLiteral": 1, "*ast.Boolean": 1,
+			},
+		},
+		{
+			"let = 1; f(1, );",
+			map[string]int{
+				"*ast.Program": 1, "*ast.BadStatement": 1, "*ast.ExpressionStatement": 1,
+				"*ast.CallExpression": 1, "*ast.Identifier": 1, "*ast.IntegerLiteral": 1,
+				"*ast.BadExpression": 1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+
+		counts := map[string]int{}
+		ast.Inspect(program, func(node ast.Node) bool {
+			if node != nil {
+				counts[fmt.Sprintf("%T", node)] += 1
+			}
+			return true
+		})
+
+		if len(counts) != len(tt.expected) {
+			t.Errorf("wrong node types for %q.\nexpected=%v\ngot=     %v", tt.input, tt.expected, counts)
+			continue
+		}
+		for typ, n := range tt.expected {
+			if counts[typ] != n {
+				t.Errorf("wrong number of %s in %q. expected=%d, got=%d", typ, tt.input, n, counts[typ])
+			}
+		}
+	}
+}
+
+// returning false from the inspect function skips the node's children
+func TestInspectSkipsChildren(t *testing.T) {
+	program := parser.New(lexer.New("let f = fn(x) { x + y }; f(z);")).ParseProgram()
+
+	var names []string
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.FunctionLiteral:
+			return false
+		case *ast.Identifier:
+			names = append(names, node.Value)
+		}
+		return true
+	})
+
+	if strings.Join(names, " ") != "f f z" {
+		t.Errorf("wrong identifiers visited. expected=%q, got=%q", "f f z", strings.Join(names, " "))
+	}
+}
+
+// records the nodes a walk visits, indented by how deep they are
+type recorder struct {
+	depth int
+	lines *[]string
+}
+
+func (r recorder) Visit(node ast.Node) ast.Visitor {
+	if node == nil {
+		*r.lines = append(*r.lines, strings.Repeat("  ", r.depth-1)+"end")
+		return nil
+	}
+	*r.lines = append(*r.lines, strings.Repeat("  ", r.depth)+node.String())
+	return recorder{depth: r.depth + 1, lines: r.lines}
+}
+
+// children are visited in source order, each node's visit ends with Visit(nil)
+func TestWalkOrder(t *testing.T) {
+	program := parser.New(lexer.New("-a * b(c)")).ParseProgram()
+
+	var lines []string
+	ast.Walk(recorder{lines: &lines}, program)
+
+	expected := []string{
+		"((-a) * b(c))",
+		"  ((-a) * b(c))",
+		"    ((-a) * b(c))",
+		"      (-a)",
+		"        a",
+		"        end",
+		"      end",
+		"      b(c)",
+		"        b",
+		"        end",
+		"        c",
+		"        end",
+		"      end",
+		"    end",
+		"  end",
+		"end",
+	}
+
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("walk wrong.\nexpected=\n%s\ngot=\n%s", strings.Join(expected, "\n"), strings.Join(lines, "\n"))
+	}
+}
+
+// trees built by hand may leave out optional children
+func TestWalkSkipsMissingChildren(t *testing.T) {
+	nodes := []ast.Node{
+		&ast.LetStatement{Name: &ast.Identifier{Value: "x"}},
+		&ast.ReturnStatement{},
+		&ast.ExpressionStatement{},
+		&ast.IfExpression{Condition: &ast.Boolean{}, Consequence: &ast.BlockStatement{}},
+		&ast.Comment{},
+	}
+
+	for _, node := range nodes {
+		count := 0
+		ast.Inspect(node, func(n ast.Node) bool {
+			if n != nil {
+				count += 1
+			}
+			return true
+		})
+		if count == 0 {
+			t.Errorf("%T was not visited", node)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/ast"
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/lexer"
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/parser"
+)
+
+// runDump prints the syntax tree of each file named in args, or of standard
+// input, in format "tree" or "dot" and returns the exit status
+func runDump(format string, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var dump func(io.Writer, ast.Node) error
+	switch format {
+	case "tree":
+		dump = ast.Fprint
+	case "dot":
+		dump = ast.FprintDOT
+	default:
+		fmt.Fprintf(stderr, "-dump: unknown format %q, use tree or dot\n", format)
+		return 2
+	}
+
+	dumpFile := func(filename string, src []byte) bool {
+		p := parser.New(lexer.New(string(src), lexer.WithFilename(filename)))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			io.WriteString(stderr, p.Errors().Render(string(src)))
+			return false
+		}
+		if err := dump(stdout, program); err != nil {
+			fmt.Fprintln(stderr, err)
+			return false
+		}
+		return true
+	}
+
+	if len(args) == 0 {
+		src, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "-dump: %s\n", err)
+			return 1
+		}
+		if !dumpFile("", src) {
+			return 1
+		}
+		return 0
+	}
+
+	status := 0
+	for _, filename := range args {
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			status = 1
+			continue
+		}
+		if !dumpFile(filename, src) {
+			status = 1
+		}
+	}
+	return status
+}
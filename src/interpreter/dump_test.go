@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunDump(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"tree", "Program\n.  statements[0]: ExpressionStatement 1:1\n.  .  expression: Identifier x 1:1\n"},
+		{"dot", "digraph ast {\n"},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		status := runDump(tt.format, nil, strings.NewReader("x"), &stdout, &stderr)
+
+		if status != 0 || !strings.HasPrefix(stdout.String(), tt.expected) {
+			t.Errorf("-dump=%s wrong. status=%d, output=%q, errors=%q", tt.format, status, stdout.String(), stderr.String())
+		}
+	}
+}
+
+func TestRunDumpErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if status := runDump("svg", nil, strings.NewReader("x"), &stdout, &stderr); status != 2 {
+		t.Errorf("wrong status for unknown format. expected=2, got=%d", status)
+	}
+
+	filename := filepath.Join(t.TempDir(), "bad.chl")
+	if err := os.WriteFile(filename, []byte("let x 5"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	status := runDump("tree", []string{filename}, nil, &stdout, &stderr)
+
+	if status != 1 || stdout.Len() != 0 {
+		t.Errorf("tree printed for source with errors. status=%d, output=%q", status, stdout.String())
+	}
+	expected := filename + ":1:7: expected next token to be =, got INT instead\nlet x 5\n      ^\n"
+	if stderr.String() != expected {
+		t.Errorf("wrong errors. expected=%q, got=%q", expected, stderr.String())
+	}
+}
@@ -0,0 +1,190 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/object"
+)
+
+// integer arithmetic is done on int64 while the result fits and falls back
+// to math/big when it doesn't, so integers never silently wrap around
+
+// the largest result of ** in bits, about 315,000 decimal digits
+const maxPowerBits = 1 << 20
+
+func evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
+	leftInt, leftOk := left.(*object.Integer)
+	rightInt, rightOk := right.(*object.Integer)
+
+	if leftOk && rightOk {
+		if result, ok := evalInt64InfixExpression(operator, leftInt.Value, rightInt.Value); ok {
+			return result
+		}
+	}
+
+	return evalBigIntegerInfixExpression(operator, toBigInt(left), toBigInt(right))
+}
+
+// ok is false if the result would overflow an int64
+func evalInt64InfixExpression(operator string, leftVal, rightVal int64) (result object.Object, ok bool) {
+	switch operator {
+	case "+":
+		sum := leftVal + rightVal
+		// overflowed if both operands have the same sign and the sum doesn't
+		if (leftVal^sum)&(rightVal^sum) < 0 {
+			return nil, false
+		}
+		return &object.Integer{Value: sum}, true
+	case "-":
+		diff := leftVal - rightVal
+		// overflowed if the operands have different signs and the difference doesn't have the left one's
+		if (leftVal^rightVal)&(leftVal^diff) < 0 {
+			return nil, false
+		}
+		return &object.Integer{Value: diff}, true
+	case "*":
+		product, ok := mulInt64(leftVal, rightVal)
+		if !ok {
+			return nil, false
+		}
+		return &object.Integer{Value: product}, true
+	case "/":
+		if rightVal == 0 {
+			return newError("division by zero: %d / %d", leftVal, rightVal), true
+		}
+		// the only division that overflows
+		if leftVal == math.MinInt64 && rightVal == -1 {
+			return nil, false
+		}
+		return &object.Integer{Value: leftVal / rightVal}, true
+	case "%":
+		if rightVal == 0 {
+			return newError("division by zero: %d %% %d", leftVal, rightVal), true
+		}
+		// the remainder takes the sign of the left side, matching / truncating towards zero
+		return &object.Integer{Value: leftVal % rightVal}, true
+	case "**":
+		if rightVal < 0 {
+			return newError("negative exponent: %d ** %d", leftVal, rightVal), true
+		}
+		power, ok := powInt64(leftVal, rightVal)
+		if !ok {
+			return nil, false
+		}
+		return &object.Integer{Value: power}, true
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal), true
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal), true
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal), true
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal), true
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal), true
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal), true
+	default:
+		return newError("unknown operator: %s %s %s", object.INTEGER_OBJ, operator, object.INTEGER_OBJ), true
+	}
+}
+
+func evalBigIntegerInfixExpression(operator string, leftVal, rightVal *big.Int) object.Object {
+	switch operator {
+	case "+":
+		return object.NewInteger(new(big.Int).Add(leftVal, rightVal))
+	case "-":
+		return object.NewInteger(new(big.Int).Sub(leftVal, rightVal))
+	case "*":
+		return object.NewInteger(new(big.Int).Mul(leftVal, rightVal))
+	case "/":
+		if rightVal.Sign() == 0 {
+			return newError("division by zero: %s / %s", leftVal, rightVal)
+		}
+		// Quo truncates towards zero like int64 division does
+		return object.NewInteger(new(big.Int).Quo(leftVal, rightVal))
+	case "%":
+		if rightVal.Sign() == 0 {
+			return newError("division by zero: %s %% %s", leftVal, rightVal)
+		}
+		// Rem goes with Quo, so the sign follows the left side like int64 %
+		return object.NewInteger(new(big.Int).Rem(leftVal, rightVal))
+	case "**":
+		if rightVal.Sign() < 0 {
+			return newError("negative exponent: %s ** %s", leftVal, rightVal)
+		}
+		// only 0, 1 and -1 stay small whatever the power, anything else could
+		// take so long to compute that it has to be refused up front
+		if leftVal.CmpAbs(big.NewInt(1)) > 0 &&
+			(!rightVal.IsInt64() || rightVal.Int64() > maxPowerBits/int64(leftVal.BitLen())) {
+			return newError("exponent too large: %s ** %s", leftVal, rightVal)
+		}
+		return object.NewInteger(new(big.Int).Exp(leftVal, rightVal, nil))
+	case "<":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) < 0)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) > 0)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) <= 0)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) >= 0)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) != 0)
+	default:
+		return newError("unknown operator: %s %s %s", object.INTEGER_OBJ, operator, object.INTEGER_OBJ)
+	}
+}
+
+// ok is false if the product overflows an int64
+func mulInt64(leftVal, rightVal int64) (int64, bool) {
+	product := leftVal * rightVal
+	if leftVal != 0 && (product/leftVal != rightVal || leftVal == -1 && rightVal == math.MinInt64) {
+		return 0, false
+	}
+	return product, true
+}
+
+// exponentiation by squaring, ok is false if the result overflows an int64.
+// exp must not be negative
+func powInt64(base, exp int64) (int64, bool) {
+	result := int64(1)
+	for exp > 0 {
+		var ok bool
+		if exp&1 == 1 {
+			if result, ok = mulInt64(result, base); !ok {
+				return 0, false
+			}
+		}
+		exp >>= 1
+		// squaring once more than needed could overflow when the result doesn't
+		if exp > 0 {
+			if base, ok = mulInt64(base, base); !ok {
+				return 0, false
+			}
+		}
+	}
+	return result, true
+}
+
+func evalIntegerNegation(right object.Object) object.Object {
+	if integer, ok := right.(*object.Integer); ok && integer.Value != math.MinInt64 {
+		return &object.Integer{Value: -integer.Value}
+	}
+
+	return object.NewInteger(new(big.Int).Neg(toBigInt(right)))
+}
+
+// converts an Integer or BigInteger to a *big.Int, callers check the type first
+func toBigInt(obj object.Object) *big.Int {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return big.NewInt(obj.Value)
+	case *object.BigInteger:
+		return obj.Value
+	default:
+		return new(big.Int)
+	}
+}
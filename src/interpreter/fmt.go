@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/internal/diff"
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/lexer"
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/parser"
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/printer"
+)
+
+// runFmt is the fmt subcommand, it formats the files named in args or standard
+// input and returns the exit status
+func runFmt(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	write := flags.Bool("w", false, "write the result to the file instead of standard output")
+	showDiff := flags.Bool("d", false, "print a diff of the changes instead of the formatted source")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: fmt [-w] [-d] [file ...]\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintf(stderr, "fmt: can't use -w on standard input\n")
+			return 2
+		}
+		src, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "fmt: %s\n", err)
+			return 1
+		}
+		if err := formatSource("<standard input>", src, false, *showDiff, stdout); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	status := 0
+	for _, filename := range flags.Args() {
+		src, err := os.ReadFile(filename)
+		if err == nil {
+			err = formatSource(filename, src, *write, *showDiff, stdout)
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			status = 1
+		}
+	}
+	return status
+}
+
+// formats src read from filename, then writes it back, prints a diff or
+// prints the result depending on the flags
+func formatSource(filename string, src []byte, write, showDiff bool, stdout io.Writer) error {
+	res, err := printer.Format(src, lexer.WithFilename(filename))
+	if errs, ok := err.(parser.ErrorList); ok {
+		// every error with the line it is on, the caller adds the last newline
+		return errors.New(strings.TrimSuffix(errs.Render(string(src)), "\n"))
+	}
+	if err != nil {
+		return err
+	}
+
+	if !write && !showDiff {
+		_, err := stdout.Write(res)
+		return err
+	}
+	if bytes.Equal(src, res) {
+		return nil
+	}
+
+	if write {
+		info, err := os.Stat(filename)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filename, res, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	if showDiff {
+		if _, err := stdout.Write(unifiedDiff(filename, src, res)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lines of unchanged context around each change in a diff
+const diffContext = 3
+
+// unifiedDiff returns the changes that turn old into new in unified diff format
+func unifiedDiff(filename string, old, new []byte) []byte {
+	lines := diff.Lines(splitLines(old), splitLines(new))
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", filename, filename)
+
+	// lines of old and new before each line of the diff, for the hunk headers
+	oldBefore := make([]int, len(lines)+1)
+	newBefore := make([]int, len(lines)+1)
+	for i, line := range lines {
+		oldBefore[i+1], newBefore[i+1] = oldBefore[i], newBefore[i]
+		if line.Op != '+' {
+			oldBefore[i+1] += 1
+		}
+		if line.Op != '-' {
+			newBefore[i+1] += 1
+		}
+	}
+
+	for i := 0; i < len(lines); {
+		if lines[i].Op == ' ' {
+			i += 1
+			continue
+		}
+
+		// a hunk takes in every change that is close enough to share context with the last
+		last := i
+		for j := i; j < len(lines) && j-last <= 2*diffContext; j++ {
+			if lines[j].Op != ' ' {
+				last = j
+			}
+		}
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := last + diffContext + 1
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n",
+			hunkRange(oldBefore[start], oldBefore[end]), hunkRange(newBefore[start], newBefore[end]))
+		for _, line := range lines[start:end] {
+			out.WriteByte(line.Op)
+			out.WriteString(line.Text)
+			if !strings.HasSuffix(line.Text, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+
+	return out.Bytes()
+}
+
+// the lines from after line from up to line to, an empty range is given by the line before it
+func hunkRange(from, to int) string {
+	if to == from {
+		return fmt.Sprintf("%d,0", from)
+	}
+	return fmt.Sprintf("%d,%d", from+1, to-from)
+}
+
+// splits s after each newline, so a last line without one can be told apart
+func splitLines(s []byte) []string {
+	lines := strings.SplitAfter(string(s), "\n")
+	// there is an empty string after a final newline
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
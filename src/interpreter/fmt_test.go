@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm"
+	new := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+
+	expected := `--- a/x.chl
++++ b/x.chl
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -10,4 +10,5 @@
+ j
+ k
+ l
+-m
+\ No newline at end of file
++m
++n
+`
+
+	actual := string(unifiedDiff("x.chl", []byte(old), []byte(new)))
+	if actual != expected {
+		t.Errorf("diff wrong.\nexpected=\n%s\ngot=\n%s", expected, actual)
+	}
+}
+
+func TestUnifiedDiffJoinsCloseChanges(t *testing.T) {
+	actual := string(unifiedDiff("x", []byte("1\n2\n3\n4\n5\n6\n7\n8\n"), []byte("1\nX\n3\n4\n5\n6\n7\nY\n")))
+
+	if strings.Count(actual, "@@ -") != 1 {
+		t.Errorf("changes 6 lines apart not in one hunk.\n%s", actual)
+	}
+	if !strings.Contains(actual, "@@ -1,8 +1,8 @@\n") {
+		t.Errorf("wrong hunk header.\n%s", actual)
+	}
+}
+
+func TestRunFmt(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "add.chl")
+	if err := os.WriteFile(filename, []byte("let add=fn(a,b){a+b}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	formatted := "let add = fn(a, b) {\n\ta + b;\n};\n"
+
+	// -d leaves the file alone
+	var stdout, stderr bytes.Buffer
+	if status := runFmt([]string{"-d", filename}, nil, &stdout, &stderr); status != 0 {
+		t.Fatalf("fmt -d failed with status %d: %s", status, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "-let add=fn(a,b){a+b}\n+let add = fn(a, b) {\n") {
+		t.Errorf("fmt -d printed the wrong diff.\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	if status := runFmt([]string{"-w", filename}, nil, &stdout, &stderr); status != 0 {
+		t.Fatalf("fmt -w failed with status %d: %s", status, stderr.String())
+	}
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(src) != formatted {
+		t.Errorf("fmt -w wrote the wrong source. expected=%q, got=%q", formatted, src)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("fmt -w printed output. got=%q", stdout.String())
+	}
+
+	// nothing is left to change
+	if status := runFmt([]string{"-d", filename}, nil, &stdout, &stderr); status != 0 || stdout.Len() != 0 {
+		t.Errorf("fmt -d on formatted file. status=%d, output=%q", status, stdout.String())
+	}
+}
+
+func TestRunFmtStdin(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	status := runFmt(nil, strings.NewReader("1+2"), &stdout, &stderr)
+
+	if status != 0 || stdout.String() != "1 + 2;\n" {
+		t.Errorf("fmt on standard input wrong. status=%d, output=%q, errors=%q", status, stdout.String(), stderr.String())
+	}
+}
+
+func TestRunFmtSyntaxError(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "bad.chl")
+	if err := os.WriteFile(filename, []byte("let = 1;\nlet y 2;"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	status := runFmt([]string{"-w", filename}, nil, &stdout, &stderr)
+
+	if status != 1 {
+		t.Errorf("wrong status. expected=1, got=%d", status)
+	}
+	// every error is shown with the line it is on
+	expected := filename + ":1:5: expected next token to be IDENT, got = instead\nlet = 1;\n    ^\n" +
+		filename + ":2:7: expected next token to be =, got INT instead\nlet y 2;\n      ^\n"
+	if stderr.String() != expected {
+		t.Errorf("wrong error. expected=%q, got=%q", expected, stderr.String())
+	}
+	if src, _ := os.ReadFile(filename); string(src) != "let = 1;\nlet y 2;" {
+		t.Errorf("file with a syntax error was rewritten. got=%q", src)
+	}
+}
@@ -0,0 +1,136 @@
+// Package diff finds the lines that differ between two versions of a text
+package diff
+
+// Line is a line of a diff
+type Line struct {
+	Op   byte // ' ' for a line in both, '-' for one only in the old text, '+' only in the new
+	Text string
+}
+
+// Lines lines up a and b along a shortest edit script with Myers' algorithm,
+// in space linear in the number of lines. within each run of changes the lines
+// only in a come before the lines only in b
+func Lines(a, b []string) []Line {
+	var lines []Line
+	diffRange(a, b, &lines)
+
+	for i := 0; i < len(lines); {
+		if lines[i].Op == ' ' {
+			i += 1
+			continue
+		}
+		j := i
+		var added []Line
+		for ; j < len(lines) && lines[j].Op != ' '; j++ {
+			if lines[j].Op == '+' {
+				added = append(added, lines[j])
+			}
+		}
+		n := i
+		for _, line := range lines[i:j] {
+			if line.Op == '-' {
+				lines[n] = line
+				n += 1
+			}
+		}
+		copy(lines[n:j], added)
+		i = j
+	}
+	return lines
+}
+
+// appends the diff of a and b to lines, splitting it at the middle snake of
+// an edit script once the lines they start and end with in common are taken off
+func diffRange(a, b []string, lines *[]Line) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix += 1
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix += 1
+	}
+
+	for _, line := range a[:prefix] {
+		*lines = append(*lines, Line{' ', line})
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	switch {
+	case len(midA) == 0:
+		for _, line := range midB {
+			*lines = append(*lines, Line{'+', line})
+		}
+	case len(midB) == 0:
+		for _, line := range midA {
+			*lines = append(*lines, Line{'-', line})
+		}
+	default:
+		x, y, u, v := middleSnake(midA, midB)
+		diffRange(midA[:x], midB[:y], lines)
+		for _, line := range midA[x:u] {
+			*lines = append(*lines, Line{' ', line})
+		}
+		diffRange(midA[u:], midB[v:], lines)
+	}
+	for _, line := range a[len(a)-suffix:] {
+		*lines = append(*lines, Line{' ', line})
+	}
+}
+
+// middleSnake searches for a shortest edit script from both ends of a and b at
+// once and returns the run of common lines a[x:u], b[y:v] where the two meet.
+// forward[k] holds how far along a the furthest path on diagonal k got, in
+// backward the diagonals and distances count from the ends of a and b
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	max := (n + m + 1) / 2
+	// diagonals run from -max-1 to max+1
+	offset := max + 1
+	forward := make([]int, 2*max+3)
+	backward := make([]int, 2*max+3)
+
+	for d := 0; d <= max; d++ {
+		for k := -d; k <= d; k += 2 {
+			var i int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				i = forward[offset+k+1]
+			} else {
+				i = forward[offset+k-1] + 1
+			}
+			j := i - k
+			startI, startJ := i, j
+			for i < n && j < m && a[i] == b[j] {
+				i, j = i+1, j+1
+			}
+			forward[offset+k] = i
+
+			// the backward search has taken d-1 steps, the paths meet on an odd delta
+			if c := delta - k; delta%2 != 0 && c >= -(d-1) && c <= d-1 && i+backward[offset+c] >= n {
+				return startI, startJ, i, j
+			}
+		}
+
+		for c := -d; c <= d; c += 2 {
+			var i int
+			if c == -d || (c != d && backward[offset+c-1] < backward[offset+c+1]) {
+				i = backward[offset+c+1]
+			} else {
+				i = backward[offset+c-1] + 1
+			}
+			j := i - c
+			startI, startJ := i, j
+			for i < n && j < m && a[n-1-i] == b[m-1-j] {
+				i, j = i+1, j+1
+			}
+			backward[offset+c] = i
+
+			// both searches have taken d steps, the paths meet on an even delta
+			if k := delta - c; delta%2 == 0 && k >= -d && k <= d && forward[offset+k]+i >= n {
+				return n - i, m - j, n - startI, m - startJ
+			}
+		}
+	}
+	// the searches always meet by the time d reaches max, TestLinesRandom checks it
+	panic("diff: no middle snake")
+}
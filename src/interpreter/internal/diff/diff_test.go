@@ -0,0 +1,122 @@
+package diff
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		a, b     []string
+		expected []Line
+	}{
+		{nil, nil, nil},
+		{nil, []string{"x"}, []Line{{'+', "x"}}},
+		{[]string{"x", "y"}, nil, []Line{{'-', "x"}, {'-', "y"}}},
+		{[]string{"x"}, []string{"x"}, []Line{{' ', "x"}}},
+		{
+			[]string{"a", "b", "c"},
+			[]string{"a", "B", "c", "d"},
+			[]Line{{' ', "a"}, {'-', "b"}, {'+', "B"}, {' ', "c"}, {'+', "d"}},
+		},
+		// the lines only in a come first in a run of changes
+		{
+			[]string{"a", "b", "c"},
+			[]string{"x", "b", "y"},
+			[]Line{{'-', "a"}, {'+', "x"}, {' ', "b"}, {'-', "c"}, {'+', "y"}},
+		},
+	}
+
+	for _, tt := range tests {
+		actual := Lines(tt.a, tt.b)
+		if !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("Lines(%q, %q) wrong.\nexpected=%q\ngot=     %q", tt.a, tt.b, tt.expected, actual)
+		}
+	}
+}
+
+// on random input Lines keeps a longest common subsequence, found the slow way,
+// and the lines it gives are a and b again. every size from empty up is
+// tried, so the search for a middle snake is seen to always end
+func TestLinesRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	lines := func(max, letters int) []string {
+		list := make([]string, r.Intn(max+1))
+		for i := range list {
+			list[i] = string(rune('a' + r.Intn(letters)))
+		}
+		return list
+	}
+
+	for i := 0; i < 20000; i++ {
+		max, letters := 10, 3
+		if i%10 == 0 {
+			max, letters = 60, 5
+		}
+		a, b := lines(max, letters), lines(max, letters)
+		diff := Lines(a, b)
+
+		var gotA, gotB []string
+		common := 0
+		for _, line := range diff {
+			if line.Op != '+' {
+				gotA = append(gotA, line.Text)
+			}
+			if line.Op != '-' {
+				gotB = append(gotB, line.Text)
+			}
+			if line.Op == ' ' {
+				common += 1
+			}
+		}
+		if !equal(gotA, a) || !equal(gotB, b) {
+			t.Fatalf("Lines(%q, %q) doesn't give back its input. got=%q", a, b, diff)
+		}
+		if expected := lcsLength(a, b); common != expected {
+			t.Fatalf("Lines(%q, %q) not the shortest. expected %d common lines, got=%d", a, b, expected, common)
+		}
+
+		// Lines only looks for a middle snake once common ends are taken off,
+		// it is found without that too
+		if len(a) > 0 && len(b) > 0 {
+			x, y, u, v := middleSnake(a, b)
+			if u-x != v-y || !equal(a[x:u], b[y:v]) {
+				t.Fatalf("middleSnake(%q, %q) wrong. got a[%d:%d], b[%d:%d]", a, b, x, u, y, v)
+			}
+		}
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// the length of the longest common subsequence of a and b, using the full table
+func lcsLength(a, b []string) int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	return lcs[0][0]
+}
@@ -1,153 +1,621 @@
 package lexer
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
 	"github.com/alex-davis-808/go-interpreter/src/interpreter/token"
 )
 
+// Lexer reads its input through a bufio.Reader so only the token being lexed
+// is held in memory, however long the input is
 type Lexer struct {
-	input        string
-	position     int  // current position in input (current char)
-	readPosition int  // current reading position in input (after current char)
-	ch           byte // current char being looked at
+	r            *bufio.Reader
+	filename     string
+	position     int  // current byte offset in input (current char)
+	readPosition int  // current reading byte offset in input (after current char)
+	ch           rune // current char being looked at
+	raw          [utf8.UTFMax]byte
+	width        int  // number of bytes of raw that hold ch as it appeared in the input
+	eof          bool // ch is the end of the input rather than a char
+	invalid      bool // ch is utf8.RuneError standing in for a byte that isn't valid UTF-8
+	line         int  // line of the current char
+	column       int  // column of the current char, counted in runes
+	text         bytes.Buffer
+	marking      bool  // chars moved past are kept in text, only while a token's text is wanted
+	readErr      error // the error that stopped reading from r, if any
+	errors       []Error
+	// emit comments as COMMENT tokens instead of skipping them
+	emitComments bool
+}
+
+// Error is a problem found while lexing, the offending input is returned as an ILLEGAL token
+type Error struct {
+	Pos token.Position
+	End token.Position
+	Msg string
+}
+
+func (e Error) Error() string {
+	return e.Pos.String() + ": " + e.Msg
+}
+
+// Option configures optional Lexer behaviour
+type Option func(*Lexer)
+
+// WithFilename sets the file name stamped onto every token position
+func WithFilename(filename string) Option {
+	return func(l *Lexer) {
+		l.filename = filename
+	}
+}
+
+// WithComments makes the lexer return comments as COMMENT tokens
+// so tools like a formatter can keep them
+func WithComments() Option {
+	return func(l *Lexer) {
+		l.emitComments = true
+	}
 }
 
 // Instantiate a new Lexer with the given input
-func New(input string) *Lexer {
-	l := &Lexer{input: input}
+func New(input string, opts ...Option) *Lexer {
+	return NewReader(strings.NewReader(input), opts...)
+}
+
+// NewReader instantiates a Lexer that reads its input from r as it goes,
+// producing the same tokens New would for the whole of r as a string.
+// r is wrapped in a bufio.Reader unless it already is one
+func NewReader(r io.Reader, opts ...Option) *Lexer {
+	l := &Lexer{r: bufio.NewReader(r), line: 1}
+	for _, opt := range opts {
+		opt(l)
+	}
 	// initialize position, readPosition and ch
 	l.readChar()
 	return l
 }
 
 // gives us next character and advance position
-// only supports ASCII to limit complexity
+// input is decoded as UTF-8 one rune at a time
 func (l *Lexer) readChar() {
 	// above syntax is how you assign a method to a struct
-	if l.readPosition >= len(l.input) {
-		// ASCII for "NUL"
-		l.ch = 0
-	} else {
-		// set current character
-		l.ch = l.input[l.readPosition]
+	// once EOF has been reached stay there so repeated calls don't drift the position
+	if l.eof {
+		return
+	}
+	// keep the char being moved past as part of the text since the last mark
+	if l.marking {
+		l.text.Write(l.raw[:l.width])
+	}
+	// moving past a newline starts a new line
+	if l.ch == '\n' {
+		l.line += 1
+		l.column = 0
 	}
 	// increment position
 	l.position = l.readPosition
-	l.readPosition += 1
+	l.column += 1
+
+	buf := l.peek(utf8.UTFMax)
+	if len(buf) == 0 {
+		if l.readErr != nil {
+			l.error(l.pos(), l.pos(), "read error: %v", l.readErr)
+		}
+		// ASCII for "NUL"
+		l.ch = 0
+		l.width = 0
+		l.invalid = false
+		l.eof = true
+		return
+	}
+
+	// set current character
+	ch, width := utf8.DecodeRune(buf)
+	l.ch = ch
+	l.width = copy(l.raw[:], buf[:width])
+	l.r.Discard(width)
+	l.readPosition += width
+
+	// a width of 1 tells a bad byte apart from a real U+FFFD in the input
+	l.invalid = ch == utf8.RuneError && width == 1
+	if l.invalid {
+		l.error(l.pos(), l.endPos(), "invalid UTF-8 encoding (byte %#x)", l.raw[0])
+	}
+}
+
+// up to the next n bytes after the current char, fewer at the end of the input.
+// after a read error no more is read from r, so the bytes read before it
+// are still lexed and the error is reported once they run out
+func (l *Lexer) peek(n int) []byte {
+	if l.readErr != nil {
+		if n > l.r.Buffered() {
+			n = l.r.Buffered()
+		}
+		buf, _ := l.r.Peek(n)
+		return buf
+	}
+
+	buf, err := l.r.Peek(n)
+	if err != nil && err != io.EOF {
+		l.readErr = err
+	}
+	return buf
+}
+
+// start collecting the input text from the current char on
+func (l *Lexer) mark() {
+	l.text.Reset()
+	l.marking = true
+}
+
+// stop collecting the input text, whitespace and skipped comments aren't kept
+// so they take no memory however long they are
+func (l *Lexer) unmark() {
+	l.text.Reset()
+	l.marking = false
+}
+
+// the input text from the last mark up to, but not including, the current char
+func (l *Lexer) marked() string {
+	return l.text.String()
+}
+
+// position of the current char
+func (l *Lexer) pos() token.Position {
+	return token.Position{
+		Filename: l.filename,
+		Offset:   l.position,
+		Line:     l.line,
+		Column:   l.column,
+	}
 }
 
-func (l *Lexer) peekChar() byte {
-	if l.readPosition >= len(l.input) {
+// position just after the current char, used to end spans that include it
+func (l *Lexer) endPos() token.Position {
+	pos := l.pos()
+	if !l.atEOF() {
+		pos.Offset = l.readPosition
+		pos.Column += 1
+	}
+	return pos
+}
+
+func (l *Lexer) atEOF() bool {
+	return l.eof
+}
+
+// getter for the errors found so far
+func (l *Lexer) Errors() []Error {
+	return l.errors
+}
+
+func (l *Lexer) error(pos, end token.Position, format string, a ...interface{}) {
+	l.errors = append(l.errors, Error{Pos: pos, End: end, Msg: fmt.Sprintf(format, a...)})
+}
+
+func (l *Lexer) peekChar() rune {
+	if l.eof {
+		return 0
+	}
+	buf := l.peek(utf8.UTFMax)
+	if len(buf) == 0 {
 		return 0
 	} else {
-		return l.input[l.readPosition]
+		ch, _ := utf8.DecodeRune(buf)
+		return ch
 	}
 }
 
-// return a TokenType and Literal for current byte then increment w/ readChar()
+// return a TokenType and Literal for current char then increment w/ readChar()
 func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
 
+	l.unmark()
 	l.skipWhitespace()
 
+	// comments are skipped like whitespace unless they were asked for
+	for l.ch == '/' && (l.peekChar() == '/' || l.peekChar() == '*') {
+		start := l.pos()
+		if !l.emitComments {
+			l.readComment(start)
+			l.skipWhitespace()
+			continue
+		}
+		l.mark()
+		comment := l.readComment(start)
+		return token.Token{Type: token.COMMENT, Literal: comment, Pos: start, End: l.pos()}
+	}
+
+	start := l.pos()
+	l.mark()
+
 	switch l.ch {
-	case '=':
-		// If there were more two-char tokens it might be a good idea to abstract away the process of looking ahead and concatenating the next char
-		if l.peekChar() == '=' {
-			ch := l.ch
-			l.readChar()
-			tok = token.Token{Type: token.EQ, Literal: string(ch) + string(l.ch)}
+	case '"':
+		if literal, ok := l.readString(start); ok {
+			tok.Type = token.STRING
+			tok.Literal = literal
 		} else {
-			tok = newToken(token.ASSIGN, l.ch)
+			// the literal is filled in below, keeping the closing quote if there is one
+			tok.Type = token.ILLEGAL
 		}
-	case '-':
-		tok = newToken(token.MINUS, l.ch)
-	case '!':
-		if l.peekChar() == '=' {
-			ch := l.ch
-			l.readChar()
-			tok = token.Token{Type: token.NOT_EQ, Literal: string(ch) + string(l.ch)}
-		} else {
-			tok = newToken(token.BANG, l.ch)
-		}
-	case '*':
-		tok = newToken(token.ASTERISK, l.ch)
-	case '/':
-		tok = newToken(token.SLASH, l.ch)
-	case '<':
-		tok = newToken(token.LT, l.ch)
-	case '>':
-		tok = newToken(token.GT, l.ch)
-	case ';':
-		tok = newToken(token.SEMICOLON, l.ch)
-	case '(':
-		tok = newToken(token.LPAREN, l.ch)
-	case ')':
-		tok = newToken(token.RPAREN, l.ch)
-	case ',':
-		tok = newToken(token.COMMA, l.ch)
-	case '+':
-		tok = newToken(token.PLUS, l.ch)
-	case '{':
-		tok = newToken(token.LBRACE, l.ch)
-	case '}':
-		tok = newToken(token.RBRACE, l.ch)
 	case 0:
-		tok.Literal = ""
-		tok.Type = token.EOF
+		// only the end of the input is EOF, a NUL char in it is as illegal as any other
+		if l.atEOF() {
+			tok.Literal = ""
+			tok.Type = token.EOF
+			break
+		}
+		fallthrough
 
 		// read identifier if ch is legal symbol
 	default:
-		if isLetter(l.ch) {
+		if op := l.matchOperator(); op != "" {
+			// the last char is read below like for every other token
+			for i := 1; i < len(op); i++ {
+				l.readChar()
+			}
+			tok = token.Token{Type: op, Literal: string(op)}
+		} else if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
+			tok.Pos, tok.End = start, l.pos()
 			return tok
-		} else if isDigit(l.ch) {
-			tok.Type = token.INT
-			tok.Literal = l.readNumber()
+		} else if isDigit(l.ch) || l.ch == '.' && isDigit(l.peekChar()) {
+			tok.Type, tok.Literal = l.readNumber(start)
+			tok.Pos, tok.End = start, l.pos()
 			return tok
 		} else {
-			tok = newToken(token.ILLEGAL, l.ch)
+			// readChar has already reported a byte that isn't valid UTF-8
+			if !l.invalid {
+				l.error(start, l.endPos(), "illegal character %q", l.ch)
+			}
+			tok.Type = token.ILLEGAL
 		}
 	}
 	l.readChar()
+	tok.Pos, tok.End = start, l.pos()
+	// illegal input is returned exactly as it was written
+	if tok.Type == token.ILLEGAL {
+		tok.Literal = l.marked()
+	}
 	return tok
 }
 
+// readIdentifier and the read functions below expect the lexer to have been
+// marked at the first char of what they read
+// operators and delimiters by their first char, the type of each is also its literal.
+// longer operators come first so the longest one that matches wins, == over =
+var operators = map[rune][]token.TokenType{
+	'=': {token.EQ, token.ASSIGN},
+	'!': {token.NOT_EQ, token.BANG},
+	'<': {token.LT_EQ, token.LT},
+	'>': {token.GT_EQ, token.GT},
+	'*': {token.POWER, token.ASTERISK},
+	'&': {token.AND},
+	'|': {token.OR},
+	'+': {token.PLUS},
+	'-': {token.MINUS},
+	'/': {token.SLASH},
+	'%': {token.PERCENT},
+	',': {token.COMMA},
+	';': {token.SEMICOLON},
+	':': {token.COLON},
+	'(': {token.LPAREN},
+	')': {token.RPAREN},
+	'{': {token.LBRACE},
+	'}': {token.RBRACE},
+	'[': {token.LBRACKET},
+	']': {token.RBRACKET},
+}
+
+// returns the longest operator starting at the current char without reading it,
+// or "" if there isn't one. operators are ASCII so the rest of one can be
+// compared to the upcoming bytes directly
+func (l *Lexer) matchOperator() token.TokenType {
+	for _, op := range operators[l.ch] {
+		rest := string(op)[1:]
+		if string(l.peek(len(rest))) == rest {
+			return op
+		}
+	}
+	return ""
+}
+
 func (l *Lexer) readIdentifier() string {
-	position := l.position
-	// while loop
-	for isLetter(l.ch) {
+	// while loop, digits are allowed after the first letter
+	for isLetter(l.ch) || unicode.IsDigit(l.ch) {
 		l.readChar()
 	}
-	return l.input[position:l.position]
+	return l.marked()
 }
 
-func (l *Lexer) readNumber() string {
-	position := l.position
+// reads an INT or a FLOAT: <digits>[.<digits>][(e|E)[+|-]<digits>]
+// or a prefixed INT: 0x<hex digits>, 0o<octal digits> or 0b<binary digits>
+// digits may be separated by underscores, as in 1_000_000.
+// a malformed number is still read as a whole so it becomes a single ILLEGAL token
+func (l *Lexer) readNumber(start token.Position) (token.TokenType, string) {
+	if l.ch == '0' && basePrefixes[l.peekChar()] != "" {
+		return l.readPrefixedInteger(start)
+	}
+
+	var tokType token.TokenType = token.INT
+	var msg string
+
+	// .5 is only let in here so it can be rejected as one token
+	if !isDigit(l.ch) {
+		msg = "floating-point literal must start with a digit"
+	}
+
 	// while loop
-	for isDigit(l.ch) {
+	for isDigit(l.ch) || l.ch == '_' {
+		l.readChar()
+	}
+
+	if l.ch == '.' {
+		tokType = token.FLOAT
+		l.readChar()
+		if !isDigit(l.ch) && msg == "" {
+			msg = "missing digits after decimal point"
+		}
+		for isDigit(l.ch) || l.ch == '_' {
+			l.readChar()
+		}
+	}
+
+	if l.ch == 'e' || l.ch == 'E' {
+		tokType = token.FLOAT
+		l.readChar()
+		if l.ch == '+' || l.ch == '-' {
+			l.readChar()
+		}
+		if !isDigit(l.ch) && msg == "" {
+			msg = "exponent has no digits"
+		}
+		for isDigit(l.ch) || l.ch == '_' {
+			l.readChar()
+		}
+	}
+
+	literal := l.marked()
+
+	if msg == "" && !validUnderscores(literal, false, isDigit) {
+		msg = "'_' must separate successive digits"
+	}
+
+	// without this 010 would silently be read as octal
+	if msg == "" && tokType == token.INT && len(literal) > 1 && literal[0] == '0' {
+		msg = "leading zeros are not allowed, use the 0o prefix for octal"
+	}
+
+	if msg != "" {
+		l.error(start, l.pos(), "invalid number %q: %s", literal, msg)
+		return token.ILLEGAL, literal
+	}
+
+	return tokType, literal
+}
+
+// reads a // comment up to the end of the line or a /* */ comment, which may
+// be nested, up to its matching */. l.ch must be the first '/'
+// and is left on the char after the comment. returns the full comment text,
+// a // comment ends before the '\r' of a "\r\n" line ending
+func (l *Lexer) readComment(start token.Position) string {
+	if l.peekChar() == '/' {
+		for l.ch != '\n' && !(l.ch == '\r' && l.peekChar() == '\n') && !l.atEOF() {
+			l.readChar()
+		}
+		return l.marked()
+	}
+
+	// skip the opening /*
+	l.readChar()
+	l.readChar()
+
+	depth := 1
+	for depth > 0 {
+		switch {
+		case l.atEOF():
+			l.error(start, l.pos(), "unterminated block comment")
+			return l.marked()
+		case l.ch == '/' && l.peekChar() == '*':
+			depth += 1
+			l.readChar()
+		case l.ch == '*' && l.peekChar() == '/':
+			depth -= 1
+			l.readChar()
+		}
+		l.readChar()
+	}
+
+	return l.marked()
+}
+
+// the letter after a leading 0 that selects the base of an integer literal
+var basePrefixes = map[rune]string{
+	'x': "hexadecimal",
+	'X': "hexadecimal",
+	'o': "octal",
+	'O': "octal",
+	'b': "binary",
+	'B': "binary",
+}
+
+// reads 0x, 0o and 0b integers, l.ch must be the leading 0
+func (l *Lexer) readPrefixedInteger(start token.Position) (token.TokenType, string) {
+	l.readChar()
+	base := basePrefixes[l.ch]
+	l.readChar()
+
+	// read every letter and digit so a bad digit doesn't split the literal in two
+	for isLetter(l.ch) || isDigit(l.ch) {
+		l.readChar()
+	}
+
+	literal := l.marked()
+	digits := literal[2:]
+
+	var isBaseDigit func(rune) bool
+	switch base {
+	case "hexadecimal":
+		isBaseDigit = isHexDigit
+	case "octal":
+		isBaseDigit = func(ch rune) bool { return '0' <= ch && ch <= '7' }
+	case "binary":
+		isBaseDigit = func(ch rune) bool { return ch == '0' || ch == '1' }
+	}
+
+	msg := ""
+	if strings.Trim(digits, "_") == "" {
+		msg = base + " literal has no digits"
+	}
+	for _, ch := range digits {
+		if ch != '_' && !isBaseDigit(ch) {
+			msg = fmt.Sprintf("invalid digit %q in %s literal", ch, base)
+			break
+		}
+	}
+	if msg == "" && !validUnderscores(digits, true, isBaseDigit) {
+		msg = "'_' must separate successive digits"
+	}
+
+	if msg != "" {
+		l.error(start, l.pos(), "invalid number %q: %s", literal, msg)
+		return token.ILLEGAL, literal
+	}
+
+	return token.INT, literal
+}
+
+// underscores may only sit between two digits, or straight after a base prefix
+// when afterPrefix is set, as in 0x_ff
+func validUnderscores(literal string, afterPrefix bool, isDigit func(rune) bool) bool {
+	for i := 0; i < len(literal); i++ {
+		if literal[i] != '_' {
+			continue
+		}
+
+		// digits are ASCII so looking at single bytes is enough
+		prevOK := i == 0 && afterPrefix || i > 0 && isDigit(rune(literal[i-1]))
+		nextOK := i+1 < len(literal) && isDigit(rune(literal[i+1]))
+		if !prevOK || !nextOK {
+			return false
+		}
+	}
+
+	return true
+}
+
+// reads a double quoted string and returns its value with escapes replaced,
+// l.ch must be the opening quote and is left on the closing quote.
+// ok is false if the string is unterminated or contains a bad escape
+func (l *Lexer) readString(start token.Position) (literal string, ok bool) {
+	var out strings.Builder
+	ok = true
+
+	for {
 		l.readChar()
+
+		switch {
+		case l.atEOF():
+			l.error(start, l.pos(), "unterminated string literal")
+			return "", false
+		case l.ch == '"':
+			return out.String(), ok
+		case l.ch == '\\':
+			if !l.readEscape(&out) {
+				ok = false
+			}
+		default:
+			out.WriteRune(l.ch)
+		}
 	}
-	return l.input[position:l.position]
+}
+
+// reads the escape sequence starting at the current '\' and writes the char it stands for
+func (l *Lexer) readEscape(out *strings.Builder) bool {
+	start := l.pos()
+	l.readChar()
 
+	switch l.ch {
+	case 'n':
+		out.WriteByte('\n')
+	case 't':
+		out.WriteByte('\t')
+	case '"':
+		out.WriteByte('"')
+	case '\\':
+		out.WriteByte('\\')
+	case 'u':
+		return l.readUnicodeEscape(start, out)
+	case 0:
+		// the string is unterminated, which readString reports
+		if l.atEOF() {
+			return false
+		}
+		fallthrough
+	default:
+		l.error(start, l.endPos(), "unknown escape sequence \\%c", l.ch)
+		return false
+	}
+
+	return true
 }
 
-func isLetter(ch byte) bool {
-	// checks that byte is within letter ASCII ranges
+// \u{<1 to 6 hex digits>}, l.ch is the 'u'
+func (l *Lexer) readUnicodeEscape(start token.Position, out *strings.Builder) bool {
+	if l.peekChar() != '{' {
+		l.error(start, l.endPos(), "\\u must be followed by {")
+		return false
+	}
+	l.readChar()
+
+	var hex strings.Builder
+	for isHexDigit(l.peekChar()) {
+		l.readChar()
+		hex.WriteRune(l.ch)
+	}
+	digits := hex.String()
+
+	if l.peekChar() != '}' {
+		l.error(start, l.endPos(), "unterminated \\u{...} escape")
+		return false
+	}
+	l.readChar()
+
+	value, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil || len(digits) > 6 || !utf8.ValidRune(rune(value)) {
+		l.error(start, l.endPos(), "invalid Unicode code point %q in escape sequence", digits)
+		return false
+	}
+
+	out.WriteRune(rune(value))
+	return true
+}
+
+func isLetter(ch rune) bool {
+	// any Unicode letter can start an identifier
 	// inclusion of '_' allows us to use it in identifiers
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+	return unicode.IsLetter(ch) || ch == '_'
 }
 
-func isDigit(ch byte) bool {
+// only ASCII digits make up numbers, identifiers also accept any Unicode digit
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
+
 func (l *Lexer) skipWhitespace() {
 	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
 		l.readChar()
 	}
 }
-
-func newToken(tokenType token.TokenType, ch byte) token.Token {
-	return token.Token{Type: tokenType, Literal: string(ch)}
-}
@@ -1,7 +1,13 @@
 package lexer
 
 import (
+	"bufio"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/alex-davis-808/go-interpreter/src/interpreter/token"
 )
@@ -14,7 +20,7 @@ func TestNextToken(t *testing.T) {
 		x + y;
 	};
 	let result = add(five, ten);
-	!-/*5;
+	!-/ *5;
 	5 < 10 > 5;
 
 	if (5 < 10) {
@@ -25,6 +31,11 @@ func TestNextToken(t *testing.T) {
 
 	10 == 10;
 	10 != 9;
+	"foobar"
+	"foo bar"
+	[1, 2];
+	{"foo": "bar"}
+	a <= b >= c && d || e % f ** g;
 	`
 
 	tests := []struct {
@@ -106,6 +117,33 @@ func TestNextToken(t *testing.T) {
 		{token.NOT_EQ, "!="},
 		{token.INT, "9"},
 		{token.SEMICOLON, ";"},
+		{token.STRING, "foobar"},
+		{token.STRING, "foo bar"},
+		{token.LBRACKET, "["},
+		{token.INT, "1"},
+		{token.COMMA, ","},
+		{token.INT, "2"},
+		{token.RBRACKET, "]"},
+		{token.SEMICOLON, ";"},
+		{token.LBRACE, "{"},
+		{token.STRING, "foo"},
+		{token.COLON, ":"},
+		{token.STRING, "bar"},
+		{token.RBRACE, "}"},
+		{token.IDENT, "a"},
+		{token.LT_EQ, "<="},
+		{token.IDENT, "b"},
+		{token.GT_EQ, ">="},
+		{token.IDENT, "c"},
+		{token.AND, "&&"},
+		{token.IDENT, "d"},
+		{token.OR, "||"},
+		{token.IDENT, "e"},
+		{token.PERCENT, "%"},
+		{token.IDENT, "f"},
+		{token.POWER, "**"},
+		{token.IDENT, "g"},
+		{token.SEMICOLON, ";"},
 		{token.EOF, ""},
 	}
 
@@ -125,3 +163,508 @@ func TestNextToken(t *testing.T) {
 		}
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := "let x = 10;\n  x != 5\n"
+
+	tests := []struct {
+		expectedType token.TokenType
+		expectedPos  token.Position
+		expectedEnd  token.Position
+	}{
+		{token.LET, testPos(0, 1, 1), testPos(3, 1, 4)},
+		{token.IDENT, testPos(4, 1, 5), testPos(5, 1, 6)},
+		{token.ASSIGN, testPos(6, 1, 7), testPos(7, 1, 8)},
+		{token.INT, testPos(8, 1, 9), testPos(10, 1, 11)},
+		{token.SEMICOLON, testPos(10, 1, 11), testPos(11, 1, 12)},
+		{token.IDENT, testPos(14, 2, 3), testPos(15, 2, 4)},
+		{token.NOT_EQ, testPos(16, 2, 5), testPos(18, 2, 7)},
+		{token.INT, testPos(19, 2, 8), testPos(20, 2, 9)},
+		{token.EOF, testPos(21, 3, 1), testPos(21, 3, 1)},
+		// repeated calls at EOF must not move the position
+		{token.EOF, testPos(21, 3, 1), testPos(21, 3, 1)},
+	}
+
+	l := New(input, WithFilename("test.chl"))
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests [%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Pos != tt.expectedPos {
+			t.Fatalf("tests [%d] - pos wrong. expected=%+v, got=%+v",
+				i, tt.expectedPos, tok.Pos)
+		}
+		if tok.End != tt.expectedEnd {
+			t.Fatalf("tests [%d] - end wrong. expected=%+v, got=%+v",
+				i, tt.expectedEnd, tok.End)
+		}
+	}
+
+	if s := tests[6].expectedPos.String(); s != "test.chl:2:5" {
+		t.Errorf("Position.String() wrong. got=%q", s)
+	}
+}
+
+// identifiers may use any Unicode letter, and digits after the first letter,
+// with columns counted in runes and offsets in bytes
+func TestUnicodeIdentifiers(t *testing.T) {
+	input := "let héllo = 日本;\nx1 + x٣ + _ü"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedPos     token.Position
+		expectedEnd     token.Position
+	}{
+		{token.LET, "let", testPos(0, 1, 1), testPos(3, 1, 4)},
+		{token.IDENT, "héllo", testPos(4, 1, 5), testPos(10, 1, 10)},
+		{token.ASSIGN, "=", testPos(11, 1, 11), testPos(12, 1, 12)},
+		{token.IDENT, "日本", testPos(13, 1, 13), testPos(19, 1, 15)},
+		{token.SEMICOLON, ";", testPos(19, 1, 15), testPos(20, 1, 16)},
+		{token.IDENT, "x1", testPos(21, 2, 1), testPos(23, 2, 3)},
+		{token.PLUS, "+", testPos(24, 2, 4), testPos(25, 2, 5)},
+		{token.IDENT, "x٣", testPos(26, 2, 6), testPos(29, 2, 8)},
+		{token.PLUS, "+", testPos(30, 2, 9), testPos(31, 2, 10)},
+		{token.IDENT, "_ü", testPos(32, 2, 11), testPos(35, 2, 13)},
+		{token.EOF, "", testPos(35, 2, 13), testPos(35, 2, 13)},
+	}
+
+	l := New(input, WithFilename("test.chl"))
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests [%d] - token wrong. expected=%q %q, got=%q %q",
+				i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+		if tok.Pos != tt.expectedPos {
+			t.Fatalf("tests [%d] - pos wrong. expected=%+v, got=%+v",
+				i, tt.expectedPos, tok.Pos)
+		}
+		if tok.End != tt.expectedEnd {
+			t.Fatalf("tests [%d] - end wrong. expected=%+v, got=%+v",
+				i, tt.expectedEnd, tok.End)
+		}
+	}
+
+	if len(l.Errors()) != 0 {
+		t.Errorf("unexpected errors: %v", l.Errors())
+	}
+}
+
+func TestStringEscapes(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedLiteral string
+	}{
+		{`""`, ""},
+		{`"a\nb"`, "a\nb"},
+		{`"a\tb"`, "a\tb"},
+		{`"say \"hi\""`, `say "hi"`},
+		{`"back\\slash"`, `back\slash`},
+		{`"\u{41}\u{e9}\u{1F600}"`, "A\u00e9\U0001F600"},
+		{"\"multi\nline\"", "multi\nline"},
+		{`"héllo, 世界 😀"`, "héllo, 世界 😀"},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+
+		if tok.Type != token.STRING {
+			t.Fatalf("tests [%d] - tokentype wrong. expected=%q, got=%q (%v)",
+				i, token.STRING, tok.Type, l.Errors())
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests [%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.End.Offset != len(tt.input) {
+			t.Fatalf("tests [%d] - end wrong. expected=%d, got=%d",
+				i, len(tt.input), tok.End.Offset)
+		}
+		if next := l.NextToken(); next.Type != token.EOF {
+			t.Fatalf("tests [%d] - expected EOF after string. got=%q", i, next.Type)
+		}
+	}
+}
+
+func TestNumberLiterals(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{"5", token.INT, "5"},
+		{"1.5", token.FLOAT, "1.5"},
+		{"0.25", token.FLOAT, "0.25"},
+		{"2e10", token.FLOAT, "2e10"},
+		{"2E10", token.FLOAT, "2E10"},
+		{"1.5e-3", token.FLOAT, "1.5e-3"},
+		{"6.02e+23", token.FLOAT, "6.02e+23"},
+		{"0", token.INT, "0"},
+		{"0x1F", token.INT, "0x1F"},
+		{"0XdeadBEEF", token.INT, "0XdeadBEEF"},
+		{"0o755", token.INT, "0o755"},
+		{"0b1010", token.INT, "0b1010"},
+		{"1_000_000", token.INT, "1_000_000"},
+		{"0x_ff_ff", token.INT, "0x_ff_ff"},
+		{"0b_1010_0101", token.INT, "0b_1010_0101"},
+		{"1_000.000_1", token.FLOAT, "1_000.000_1"},
+		{"0.5", token.FLOAT, "0.5"},
+		{"0e1", token.FLOAT, "0e1"},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests [%d] - tokentype wrong. expected=%q, got=%q (%v)",
+				i, tt.expectedType, tok.Type, l.Errors())
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests [%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+		if next := l.NextToken(); next.Type != token.EOF {
+			t.Fatalf("tests [%d] - expected EOF after number. got=%q", i, next.Type)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// leading comment
+let x = 5; // trailing comment
+/* block
+   comment */ x /* inline */ / 2
+/* outer /* nested */ still outer */ y
+//`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.COMMENT, "// leading comment"},
+		{token.LET, "let"},
+		{token.IDENT, "x"},
+		{token.ASSIGN, "="},
+		{token.INT, "5"},
+		{token.SEMICOLON, ";"},
+		{token.COMMENT, "// trailing comment"},
+		{token.COMMENT, "/* block\n   comment */"},
+		{token.IDENT, "x"},
+		{token.COMMENT, "/* inline */"},
+		{token.SLASH, "/"},
+		{token.INT, "2"},
+		{token.COMMENT, "/* outer /* nested */ still outer */"},
+		{token.IDENT, "y"},
+		{token.COMMENT, "//"},
+		{token.EOF, ""},
+	}
+
+	// with comments kept every token comes through
+	l := New(input, WithComments())
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests [%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests [%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+
+	// by default the comments are skipped and nothing else changes
+	l = New(input)
+	for i, tt := range tests {
+		if tt.expectedType == token.COMMENT {
+			continue
+		}
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests [%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+	}
+
+	if len(l.Errors()) != 0 {
+		t.Errorf("unexpected errors: %v", l.Errors())
+	}
+}
+
+func TestCRLFLineComment(t *testing.T) {
+	l := New("// a\r\nx // b\r\n", WithComments())
+
+	expected := []string{"// a", "x", "// b", ""}
+	for i, literal := range expected {
+		tok := l.NextToken()
+		if tok.Literal != literal {
+			t.Errorf("tests [%d] - literal wrong. expected=%q, got=%q", i, literal, tok.Literal)
+		}
+	}
+}
+
+func TestCommentPositions(t *testing.T) {
+	l := New("x /* a\nb */ y", WithComments(), WithFilename("test.chl"))
+	l.NextToken()
+
+	tok := l.NextToken()
+	if tok.Pos != testPos(2, 1, 3) {
+		t.Errorf("comment pos wrong. expected=%+v, got=%+v", testPos(2, 1, 3), tok.Pos)
+	}
+	if tok.End != testPos(11, 2, 5) {
+		t.Errorf("comment end wrong. expected=%+v, got=%+v", testPos(11, 2, 5), tok.End)
+	}
+
+	tok = l.NextToken()
+	if tok.Type != token.IDENT || tok.Pos != testPos(12, 2, 6) {
+		t.Errorf("token after comment wrong. got=%q at %+v", tok.Type, tok.Pos)
+	}
+}
+
+func TestUnterminatedBlockComment(t *testing.T) {
+	l := New("x /* never /* closed */ y")
+	l.NextToken()
+
+	if tok := l.NextToken(); tok.Type != token.EOF {
+		t.Fatalf("expected the rest of the input to be comment. got=%q", tok.Type)
+	}
+
+	errors := l.Errors()
+	if len(errors) != 1 || errors[0].Error() != "1:3: unterminated block comment" {
+		t.Errorf("wrong errors. got=%v", errors)
+	}
+}
+
+func TestLexerErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedLiteral string
+		expectedError   string
+	}{
+		{`"abc`, `"abc`, "1:1: unterminated string literal"},
+		{`"abc\`, `"abc\`, "1:1: unterminated string literal"},
+		{`"a\qb"`, `"a\qb"`, "1:3: unknown escape sequence \\q"},
+		{`"\u41"`, `"\u41"`, "1:2: \\u must be followed by {"},
+		{`"\u{41"`, `"\u{41"`, "1:2: unterminated \\u{...} escape"},
+		{`"\u{110000}"`, `"\u{110000}"`, `1:2: invalid Unicode code point "110000" in escape sequence`},
+		{`"\u{d800}"`, `"\u{d800}"`, `1:2: invalid Unicode code point "d800" in escape sequence`},
+		{`"\u{}"`, `"\u{}"`, `1:2: invalid Unicode code point "" in escape sequence`},
+		{"@", "@", "1:1: illegal character '@'"},
+		{"€", "€", "1:1: illegal character '€'"},
+		{"\xff", "\xff", "1:1: invalid UTF-8 encoding (byte 0xff)"},
+		{"\x00", "\x00", `1:1: illegal character '\x00'`},
+		{".5", ".5", `1:1: invalid number ".5": floating-point literal must start with a digit`},
+		{"1.", "1.", `1:1: invalid number "1.": missing digits after decimal point`},
+		{"1.e5", "1.e5", `1:1: invalid number "1.e5": missing digits after decimal point`},
+		{"2e", "2e", `1:1: invalid number "2e": exponent has no digits`},
+		{"2e+", "2e+", `1:1: invalid number "2e+": exponent has no digits`},
+		{"0x", "0x", `1:1: invalid number "0x": hexadecimal literal has no digits`},
+		{"0b_", "0b_", `1:1: invalid number "0b_": binary literal has no digits`},
+		{"0b102", "0b102", `1:1: invalid number "0b102": invalid digit '2' in binary literal`},
+		{"0o78", "0o78", `1:1: invalid number "0o78": invalid digit '8' in octal literal`},
+		{"0xfg", "0xfg", `1:1: invalid number "0xfg": invalid digit 'g' in hexadecimal literal`},
+		{"1__000", "1__000", `1:1: invalid number "1__000": '_' must separate successive digits`},
+		{"1000_", "1000_", `1:1: invalid number "1000_": '_' must separate successive digits`},
+		{"1_.5", "1_.5", `1:1: invalid number "1_.5": '_' must separate successive digits`},
+		{"0x_", "0x_", `1:1: invalid number "0x_": hexadecimal literal has no digits`},
+		{"0xf_", "0xf_", `1:1: invalid number "0xf_": '_' must separate successive digits`},
+		{"0755", "0755", `1:1: invalid number "0755": leading zeros are not allowed, use the 0o prefix for octal`},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+
+		if tok.Type != token.ILLEGAL {
+			t.Fatalf("tests [%d] - tokentype wrong. expected=%q, got=%q",
+				i, token.ILLEGAL, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests [%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		errors := l.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("tests [%d] - wrong number of errors. expected=1, got=%d (%v)",
+				i, len(errors), errors)
+		}
+		if errors[0].Error() != tt.expectedError {
+			t.Fatalf("tests [%d] - error wrong. expected=%q, got=%q",
+				i, tt.expectedError, errors[0].Error())
+		}
+	}
+}
+
+// a NUL char in the input must not be taken for its end
+func TestNULChar(t *testing.T) {
+	input := "a\x00b"
+
+	for _, l := range []*Lexer{New(input), NewReader(iotest.OneByteReader(strings.NewReader(input)))} {
+		var types []token.TokenType
+		for _, tok := range collectTokens(l) {
+			types = append(types, tok.Type)
+		}
+
+		expected := []token.TokenType{token.IDENT, token.ILLEGAL, token.IDENT, token.EOF}
+		if !reflect.DeepEqual(types, expected) {
+			t.Errorf("tokens wrong. expected=%v, got=%v", expected, types)
+		}
+		if len(l.Errors()) != 1 {
+			t.Errorf("wrong number of errors. expected=1, got=%d (%v)", len(l.Errors()), l.Errors())
+		}
+	}
+}
+
+// each operator is read as the longest one that matches, a lone & or | is illegal
+func TestLongestMatchOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []token.TokenType
+	}{
+		{"===", []token.TokenType{token.EQ, token.ASSIGN}},
+		{"<==", []token.TokenType{token.LT_EQ, token.ASSIGN}},
+		{"!==", []token.TokenType{token.NOT_EQ, token.ASSIGN}},
+		{"***", []token.TokenType{token.POWER, token.ASTERISK}},
+		{"* *", []token.TokenType{token.ASTERISK, token.ASTERISK}},
+		{"&&&", []token.TokenType{token.AND, token.ILLEGAL}},
+		{"|||", []token.TokenType{token.OR, token.ILLEGAL}},
+		{"a&b", []token.TokenType{token.IDENT, token.ILLEGAL, token.IDENT}},
+		{">=>", []token.TokenType{token.GT_EQ, token.GT}},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+
+		for j, expected := range tt.expected {
+			tok := l.NextToken()
+			if tok.Type != expected {
+				t.Fatalf("tests [%d] - token %d wrong. expected=%q, got=%q",
+					i, j, expected, tok.Type)
+			}
+		}
+		if tok := l.NextToken(); tok.Type != token.EOF {
+			t.Fatalf("tests [%d] - expected EOF. got=%q", i, tok.Type)
+		}
+	}
+}
+
+// lexing from a reader must give exactly the tokens and errors lexing the same
+// input as a string does, however the reader splits up its reads
+func TestReaderMatchesString(t *testing.T) {
+	inputs := []string{
+		"let add = fn(x, y) { x + y; };\nadd(five, ten) != 15 == !true;",
+		"if (5 < 10) { return true; } else { return false; }",
+		`{"one": 1, "two": [2, 2.5e-3, 0x_ff, 0o17, 0b1010, 1_000_000]}`,
+		`"a\n\t\"\\b" "\u{41}\u{1F600}" "héllo, 世界 😀"`,
+		"let héllo = 日本;\nx1 + x٣ + _ü",
+		"// line comment\nx /* outer /* inner */ still */ y // end",
+		"a <= b >= c && d || e % f ** g === h & i | j",
+		"@ € \xff \xe6\x97 .5 1. 2e+ 0x 0b102 1__0 0755",
+		`"abc\q" "\u{110000}" "\u41" "\u{41"`,
+		"x /* never /* closed */ y",
+		`"unterminated`,
+		"",
+	}
+	// 200 identifiers with a multibyte char sitting across every buffer boundary
+	inputs = append(inputs, strings.Repeat("aé日😀 ", 200))
+
+	readers := map[string]func(string) *Lexer{
+		"one byte": func(input string) *Lexer {
+			return NewReader(iotest.OneByteReader(strings.NewReader(input)), WithComments())
+		},
+		"half": func(input string) *Lexer {
+			return NewReader(iotest.HalfReader(strings.NewReader(input)), WithComments())
+		},
+		"data with EOF": func(input string) *Lexer {
+			return NewReader(iotest.DataErrReader(strings.NewReader(input)), WithComments())
+		},
+		"small buffer": func(input string) *Lexer {
+			return NewReader(bufio.NewReaderSize(strings.NewReader(input), 16), WithComments())
+		},
+	}
+
+	for i, input := range inputs {
+		expected := New(input, WithComments())
+		expectedTokens := collectTokens(expected)
+
+		for name, newLexer := range readers {
+			l := newLexer(input)
+			tokens := collectTokens(l)
+
+			if !reflect.DeepEqual(tokens, expectedTokens) {
+				t.Errorf("inputs [%d] - %s reader tokens differ.\nexpected=%v\ngot=     %v",
+					i, name, expectedTokens, tokens)
+			}
+			if !reflect.DeepEqual(l.Errors(), expected.Errors()) {
+				t.Errorf("inputs [%d] - %s reader errors differ.\nexpected=%v\ngot=     %v",
+					i, name, expected.Errors(), l.Errors())
+			}
+		}
+	}
+}
+
+// a failed read ends the input once the bytes read before it have been lexed
+func TestReaderError(t *testing.T) {
+	r := io.MultiReader(strings.NewReader("let x"), iotest.ErrReader(errors.New("disk on fire")))
+	l := NewReader(r)
+
+	for _, expected := range []token.TokenType{token.LET, token.IDENT, token.EOF} {
+		if tok := l.NextToken(); tok.Type != expected {
+			t.Fatalf("tokentype wrong. expected=%q, got=%q", expected, tok.Type)
+		}
+	}
+
+	errs := l.Errors()
+	if len(errs) != 1 || errs[0].Error() != "1:6: read error: disk on fire" {
+		t.Errorf("wrong errors. got=%v", errs)
+	}
+}
+
+// skipped comments and whitespace are read past without being kept
+func TestSkippedTextNotBuffered(t *testing.T) {
+	const size = 1 << 20
+	input := io.MultiReader(
+		strings.NewReader("x /*"),
+		strings.NewReader(strings.Repeat("comment ", size/8)),
+		strings.NewReader("*/"+strings.Repeat(" \n", size/2)+"// "),
+		strings.NewReader(strings.Repeat("c", size)),
+		strings.NewReader("\ny"),
+	)
+	l := NewReader(input)
+
+	for _, expected := range []string{"x", "y", ""} {
+		if tok := l.NextToken(); tok.Literal != expected {
+			t.Fatalf("literal wrong. expected=%q, got=%q", expected, tok.Literal)
+		}
+		if l.text.Cap() > 1024 {
+			t.Fatalf("text buffer grew to %d bytes", l.text.Cap())
+		}
+	}
+}
+
+func collectTokens(l *Lexer) []token.Token {
+	var tokens []token.Token
+	for {
+		tok := l.NextToken()
+		tokens = append(tokens, tok)
+		if tok.Type == token.EOF {
+			return tokens
+		}
+	}
+}
+
+func testPos(offset, line, column int) token.Position {
+	return token.Position{Filename: "test.chl", Offset: offset, Line: line, Column: column}
+}
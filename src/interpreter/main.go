@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"github.com/alex-davis-808/go-interpreter/src/interpreter/repl"
 	"os"
@@ -8,6 +9,16 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "fmt" {
+		os.Exit(runFmt(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+	}
+
+	dump := flag.String("dump", "", "print the syntax tree of the files given, or of standard input,\nas an indented \"tree\" or a Graphviz \"dot\" graph instead of starting the REPL")
+	flag.Parse()
+	if *dump != "" {
+		os.Exit(runDump(*dump, flag.Args(), os.Stdin, os.Stdout, os.Stderr))
+	}
+
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
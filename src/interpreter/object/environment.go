@@ -0,0 +1,35 @@
+package object
+
+// Environment maps names to values, each function call gets a new
+// Environment enclosed by the one the function was defined in
+type Environment struct {
+	store map[string]Object
+	outer *Environment
+}
+
+func NewEnvironment() *Environment {
+	s := make(map[string]Object)
+	return &Environment{store: s, outer: nil}
+}
+
+// creates a new scope whose lookups fall back to outer
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	env := NewEnvironment()
+	env.outer = outer
+	return env
+}
+
+// looks up name in this scope and then in every enclosing scope
+func (e *Environment) Get(name string) (Object, bool) {
+	obj, ok := e.store[name]
+	if !ok && e.outer != nil {
+		obj, ok = e.outer.Get(name)
+	}
+	return obj, ok
+}
+
+// binds name in this scope only, shadowing any outer binding
+func (e *Environment) Set(name string, val Object) Object {
+	e.store[name] = val
+	return val
+}
@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/token"
+)
+
+// ErrorCode identifies the kind of a ParseError so tools don't have to match on messages
+type ErrorCode string
+
+const (
+	ErrUnexpectedToken ErrorCode = "P001" // the next token was not the one the grammar requires
+	ErrNoPrefixParseFn ErrorCode = "P002" // the token can't start an expression
+	ErrInvalidInteger  ErrorCode = "P003" // an INT token could not be converted to a value
+	ErrIllegalToken    ErrorCode = "P004" // the lexer could not make a valid token out of the input
+	ErrInvalidFloat    ErrorCode = "P005" // a FLOAT token could not be converted to a value
+)
+
+// ParseError is a single problem found while parsing, located by the offending token
+type ParseError struct {
+	Pos      token.Position // start of the offending token
+	End      token.Position // position just after the offending token
+	Expected token.TokenType
+	Actual   token.TokenType
+	Msg      string
+	Code     ErrorCode
+}
+
+// Error satisfies the error interface, prefixing the message with its position when known
+func (e *ParseError) Error() string {
+	if e.Pos.IsValid() {
+		return e.Pos.String() + ": " + e.Msg
+	}
+	return e.Msg
+}
+
+// Render returns the error message followed by the offending source line
+// with the token underlined by carets, src must be the input given to the lexer
+func (e *ParseError) Render(src string) string {
+	var out bytes.Buffer
+
+	out.WriteString(e.Error())
+	out.WriteString("\n")
+
+	if !e.Pos.IsValid() || e.Pos.Offset > len(src) {
+		return out.String()
+	}
+
+	// find the bounds of the line the error starts on
+	lineStart := strings.LastIndexByte(src[:e.Pos.Offset], '\n') + 1
+	lineEnd := len(src)
+	if i := strings.IndexByte(src[e.Pos.Offset:], '\n'); i >= 0 {
+		lineEnd = e.Pos.Offset + i
+	}
+	line := strings.TrimRight(src[lineStart:lineEnd], "\r")
+
+	out.WriteString(line)
+	out.WriteString("\n")
+
+	// keep tabs so the carets line up with the source line however tabs are displayed
+	for _, ch := range src[lineStart:e.Pos.Offset] {
+		if ch == '\t' {
+			out.WriteByte('\t')
+		} else {
+			out.WriteByte(' ')
+		}
+	}
+
+	// underline the whole token, but never past the end of the line and always at least one caret,
+	// offsets are in bytes so count the runes in between to get one caret per character
+	end := e.End.Offset
+	if end > lineEnd {
+		end = lineEnd
+	}
+	width := 0
+	if end > e.Pos.Offset {
+		width = utf8.RuneCountInString(src[e.Pos.Offset:end])
+	}
+	if width < 1 {
+		width = 1
+	}
+	out.WriteString(strings.Repeat("^", width))
+	out.WriteString("\n")
+
+	return out.String()
+}
+
+// ErrorList is the list of errors found by a Parser, it is itself an error
+type ErrorList []*ParseError
+
+func (l ErrorList) Len() int      { return len(l) }
+func (l ErrorList) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+func (l ErrorList) Less(i, j int) bool {
+	return l[i].Pos.Offset < l[j].Pos.Offset
+}
+
+// Sort orders the list by source position, keeping errors at the same position in insertion order
+func (l ErrorList) Sort() {
+	sort.Stable(l)
+}
+
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", l[0], len(l)-1)
+}
+
+// Err returns nil for an empty list so callers can use the usual err != nil check
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+// Render renders every error in the list against src, see ParseError.Render
+func (l ErrorList) Render(src string) string {
+	var out bytes.Buffer
+
+	for _, e := range l {
+		out.WriteString(e.Render(src))
+	}
+
+	return out.String()
+}
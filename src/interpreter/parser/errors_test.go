@@ -0,0 +1,321 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/ast"
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/lexer"
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/token"
+)
+
+func TestParseErrors(t *testing.T) {
+	input := "let = 10;\nlet x 5;"
+
+	l := lexer.New(input, lexer.WithFilename("test.chl"))
+	p := New(l)
+	p.ParseProgram()
+
+	tests := []struct {
+		expectedPos      string
+		expectedCode     ErrorCode
+		expectedExpected token.TokenType
+		expectedActual   token.TokenType
+		expectedError    string
+	}{
+		{"test.chl:1:5", ErrUnexpectedToken, token.IDENT, token.ASSIGN,
+			"test.chl:1:5: expected next token to be IDENT, got = instead"},
+		{"test.chl:2:7", ErrUnexpectedToken, token.ASSIGN, token.INT,
+			"test.chl:2:7: expected next token to be =, got INT instead"},
+	}
+
+	errors := p.Errors()
+	if len(errors) != len(tests) {
+		t.Fatalf("wrong number of errors. expected=%d, got=%d (%v)", len(tests), len(errors), errors)
+	}
+
+	for i, tt := range tests {
+		err := errors[i]
+
+		if err.Pos.String() != tt.expectedPos {
+			t.Errorf("errors[%d].Pos wrong. expected=%q, got=%q", i, tt.expectedPos, err.Pos)
+		}
+		if err.Code != tt.expectedCode {
+			t.Errorf("errors[%d].Code wrong. expected=%q, got=%q", i, tt.expectedCode, err.Code)
+		}
+		if err.Expected != tt.expectedExpected {
+			t.Errorf("errors[%d].Expected wrong. expected=%q, got=%q", i, tt.expectedExpected, err.Expected)
+		}
+		if err.Actual != tt.expectedActual {
+			t.Errorf("errors[%d].Actual wrong. expected=%q, got=%q", i, tt.expectedActual, err.Actual)
+		}
+		if err.Error() != tt.expectedError {
+			t.Errorf("errors[%d].Error() wrong. expected=%q, got=%q", i, tt.expectedError, err.Error())
+		}
+	}
+
+	expected := "test.chl:1:5: expected next token to be IDENT, got = instead (and 1 more errors)"
+	if errors.Error() != expected {
+		t.Errorf("ErrorList.Error() wrong. expected=%q, got=%q", expected, errors.Error())
+	}
+}
+
+func TestErrorListErr(t *testing.T) {
+	p := New(lexer.New("5;"))
+	p.ParseProgram()
+
+	if err := p.Errors().Err(); err != nil {
+		t.Errorf("Err() of empty list not nil. got=%v", err)
+	}
+}
+
+func TestRenderError(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"let x 5;",
+			"1:7: expected next token to be =, got INT instead\n" +
+				"let x 5;\n" +
+				"      ^\n",
+		},
+		{
+			"5;\n\tlet x 100;\n",
+			"2:8: expected next token to be =, got INT instead\n" +
+				"\tlet x 100;\n" +
+				"\t      ^^^\n",
+		},
+		{
+			"let 名前 \"日本\";",
+			"1:8: expected next token to be =, got STRING instead\n" +
+				"let 名前 \"日本\";\n" +
+				"       ^^^^\n",
+		},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("wrong number of errors. expected=1, got=%d (%v)", len(errors), errors)
+		}
+
+		actual := errors.Render(tt.input)
+		if actual != tt.expected {
+			t.Errorf("Render() wrong.\nexpected=%q\ngot=     %q", tt.expected, actual)
+		}
+	}
+}
+
+// errors found by the lexer come out of the parser in source order with its own
+func TestLexerErrorsReported(t *testing.T) {
+	input := "let x = \"abc\\q\";\nlet = 5;\nlet s = \"oops"
+
+	p := New(lexer.New(input))
+	p.ParseProgram()
+
+	expected := []struct {
+		code ErrorCode
+		msg  string
+	}{
+		{ErrIllegalToken, "1:13: unknown escape sequence \\q"},
+		{ErrUnexpectedToken, "2:5: expected next token to be IDENT, got = instead"},
+		{ErrIllegalToken, "3:9: unterminated string literal"},
+	}
+
+	errors := p.Errors()
+	if len(errors) != len(expected) {
+		t.Fatalf("wrong number of errors. expected=%d, got=%d (%v)", len(expected), len(errors), errors)
+	}
+
+	for i, e := range expected {
+		if errors[i].Code != e.code || errors[i].Error() != e.msg {
+			t.Errorf("errors[%d] wrong. expected=%s %q, got=%s %q", i, e.code, e.msg, errors[i].Code, errors[i].Error())
+		}
+	}
+}
+
+func TestInvalidNumberErrors(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedCode ErrorCode
+		expectedMsg  string
+	}{
+		{"let x = .5;", ErrIllegalToken, `1:9: invalid number ".5": floating-point literal must start with a digit`},
+		{"1e400", ErrInvalidFloat, `1:1: could not parse "1e400" as float`},
+		{"0b12", ErrIllegalToken, `1:1: invalid number "0b12": invalid digit '2' in binary literal`},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("wrong number of errors for %q. expected=1, got=%d (%v)", tt.input, len(errors), errors)
+		}
+		if errors[0].Code != tt.expectedCode || errors[0].Error() != tt.expectedMsg {
+			t.Errorf("wrong error. expected=%s %q, got=%s %q", tt.expectedCode, tt.expectedMsg, errors[0].Code, errors[0].Error())
+		}
+	}
+}
+
+// after a syntax error the parser skips to the next statement, so each mistake
+// gives one error and the rest of the program is still parsed
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedTree   string
+		expectedErrors []string
+	}{
+		{
+			"let = 10; let y = 2;",
+			"<bad statement>let y = 2;",
+			[]string{"1:5: expected next token to be IDENT, got = instead"},
+		},
+		{
+			"let x = (1 + 2; let y = 3;",
+			"let x = <bad expression>;let y = 3;",
+			[]string{"1:15: expected next token to be ), got ; instead"},
+		},
+		{
+			"if (x { 1 } let y = 2;",
+			"<bad expression>let y = 2;",
+			[]string{"1:7: expected next token to be ), got { instead"},
+		},
+		{
+			"let f = fn(x) { let = }; f(1);",
+			"let f = fn(x) { <bad statement> };f(1)",
+			[]string{"1:21: expected next token to be IDENT, got = instead"},
+		},
+		{
+			"let f = fn() { 1 + }; 2",
+			"let f = fn() { (1 + <bad expression>); };2",
+			[]string{"1:20: no prefix parse function for } found"},
+		},
+		{
+			"let h = {1 2}; h;",
+			"let h = <bad expression>;h",
+			[]string{"1:12: expected next token to be :, got INT instead"},
+		},
+		{
+			"let h = {1: }; h",
+			"let h = {1: <bad expression>};h",
+			[]string{"1:13: no prefix parse function for } found"},
+		},
+		{
+			"f(1, ); g(2)",
+			"f(1, <bad expression>)g(2)",
+			[]string{"1:6: no prefix parse function for ) found"},
+		},
+		{
+			"let x = 1 +\nlet y = 2;",
+			"let x = (1 + <bad expression>);let y = 2;",
+			[]string{"2:1: no prefix parse function for LET found"},
+		},
+		{
+			"if (x) { if (y) { 1 + } else { 2 } }; 3",
+			"if (x) { if (y) { (1 + <bad expression>); } else { 2; }; }3",
+			[]string{"1:23: no prefix parse function for } found"},
+		},
+		{
+			"let x = }",
+			"let x = <bad expression>;",
+			[]string{"1:9: no prefix parse function for } found"},
+		},
+		{
+			"} let x = 1;",
+			"<bad expression>let x = 1;",
+			[]string{"1:1: no prefix parse function for } found"},
+		},
+		{
+			"1; ) 2",
+			"1<bad expression>2",
+			[]string{"1:4: no prefix parse function for ) found"},
+		},
+		{
+			"1; } 2",
+			"1<bad expression>2",
+			[]string{"1:4: no prefix parse function for } found"},
+		},
+		// the lexer's error about the illegal token is the only one
+		{
+			"let @ = 5; x;",
+			"<bad statement>x",
+			[]string{"1:5: illegal character '@'"},
+		},
+		// separate mistakes are each reported
+		{
+			"let = 1; let y 2; let z = 3;",
+			"<bad statement><bad statement>let z = 3;",
+			[]string{
+				"1:5: expected next token to be IDENT, got = instead",
+				"1:16: expected next token to be =, got INT instead",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+
+		if program.String() != tt.expectedTree {
+			t.Errorf("tree for %q wrong.\nexpected=%q\ngot=     %q", tt.input, tt.expectedTree, program.String())
+		}
+
+		errors := p.Errors()
+		if len(errors) != len(tt.expectedErrors) {
+			t.Errorf("wrong number of errors for %q. expected=%d, got=%d (%v)",
+				tt.input, len(tt.expectedErrors), len(errors), errors)
+			continue
+		}
+		for i, msg := range tt.expectedErrors {
+			if errors[i].Error() != msg {
+				t.Errorf("errors[%d] for %q wrong. expected=%q, got=%q", i, tt.input, msg, errors[i].Error())
+			}
+		}
+	}
+}
+
+func TestBadStatementSpan(t *testing.T) {
+	input := "let = 10;\nlet y = 2;"
+
+	program := New(lexer.New(input)).ParseProgram()
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+
+	bad, ok := program.Statements[0].(*ast.BadStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.BadStatement. got=%T", program.Statements[0])
+	}
+	if bad.TokenLiteral() != "let" {
+		t.Errorf("bad.TokenLiteral() wrong. expected=%q, got=%q", "let", bad.TokenLiteral())
+	}
+	if bad.From.Offset != 0 || bad.To.Offset != 9 {
+		t.Errorf("bad statement span wrong. expected=0-9, got=%d-%d", bad.From.Offset, bad.To.Offset)
+	}
+}
+
+// parsing must finish with a complete tree however the input is cut short
+func TestRecoveryOnTruncatedInput(t *testing.T) {
+	input := `let add = fn(a, b) { if (a > b) { return a - b; } else { a + b } };
+let h = {"one": [1, 2.5], "two": add(3, 4)};
+h["one"][0] ** 2 % 3 <= 4 && !(true || false);`
+
+	for i := 0; i <= len(input); i++ {
+		p := New(lexer.New(input[:i]))
+		program := p.ParseProgram()
+
+		for j, stmt := range program.Statements {
+			if stmt == nil {
+				t.Fatalf("input[:%d] - program.Statements[%d] is nil", i, j)
+			}
+		}
+		// String walks the whole tree, so this panics on any nil node left in it
+		_ = program.String()
+	}
+}
@@ -2,6 +2,8 @@ package parser
 
 import (
 	"fmt"
+	"io"
+	"math/big"
 	"strconv"
 
 	"github.com/alex-davis-808/go-interpreter/src/interpreter/ast"
@@ -10,14 +12,22 @@ import (
 )
 
 var precedences = map[token.TokenType]int{
+	token.OR:       LOGICAL_OR,
+	token.AND:      LOGICAL_AND,
 	token.EQ:       EQUALS,
 	token.NOT_EQ:   EQUALS,
 	token.LT:       LESSGREATER,
 	token.GT:       LESSGREATER,
+	token.LT_EQ:    LESSGREATER,
+	token.GT_EQ:    LESSGREATER,
 	token.PLUS:     SUM,
 	token.MINUS:    SUM,
 	token.SLASH:    PRODUCT,
 	token.ASTERISK: PRODUCT,
+	token.PERCENT:  PRODUCT,
+	token.POWER:    POWER,
+	token.LPAREN:   CALL,
+	token.LBRACKET: INDEX,
 }
 
 const (
@@ -25,29 +35,77 @@ const (
 	// add the heirarchy to operators
 	_ int = iota
 	LOWEST
+	LOGICAL_OR  // X || Y
+	LOGICAL_AND // X && Y
 	EQUALS
 	LESSGREATER
 	SUM
 	PRODUCT
 	PREFIX // -X or !X
+	POWER  // X ** Y, binds tighter than prefix operators so -2 ** 2 is -(2 ** 2)
 	CALL   // myFunction(X)
+	INDEX  // array[index]
 )
 
+// Precedence returns how tightly the infix operator t binds, LOWEST if t
+// isn't an infix operator. the call '(' and index '[' count as operators
+func Precedence(t token.TokenType) int {
+	if p, ok := precedences[t]; ok {
+		return p
+	}
+	return LOWEST
+}
+
 type Parser struct {
 	l      *lexer.Lexer
-	errors []string
+	errors ErrorList
 	// similar to position and peekPosition but iterate over tokens instead of chars
 	curToken  token.Token
 	peekToken token.Token
+	// the token before curToken, and tokens to hand out again after backing up to it
+	prevToken token.Token
+	pending   []token.Token
+	// number of '{' not yet closed up to and including curToken, a stray '}'
+	// doesn't take it below 0. prevDepth is the same for prevToken
+	depth     int
+	prevDepth int
+	// position of the first token of the statement being parsed
+	stmtStart token.Position
+
+	// every error counts as a failure, but only the first one in a statement is
+	// recorded since the rest are most likely caused by it. recovering is set
+	// from that first error until the parser has skipped to the next statement
+	failures   int
+	recovering bool
+	// comments are set aside as they are read since they aren't part of the grammar
+	comments []*ast.Comment
 
 	// pass in a token type to find it's prefix/infix function
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
+
+	// where to write the trace of parse functions entered and left, nil for none
+	tracer     io.Writer
+	traceLevel int
 }
 
-func New(l *lexer.Lexer) *Parser {
+// Option configures optional Parser behaviour
+type Option func(*Parser)
+
+// WithTrace makes the parser write an indented BEGIN/END line to w
+// for every parse function it enters and leaves
+func WithTrace(w io.Writer) Option {
+	return func(p *Parser) {
+		p.tracer = w
+	}
+}
+
+func New(l *lexer.Lexer, opts ...Option) *Parser {
 	// Instantiate new parser by passing in a lexer
-	p := &Parser{l: l, errors: []string{}}
+	p := &Parser{l: l, errors: ErrorList{}}
+	for _, opt := range opts {
+		opt(p)
+	}
 
 	// make the maps specified on the type
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
@@ -55,17 +113,38 @@ func New(l *lexer.Lexer) *Parser {
 	// register fns for token types
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
+	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.ILLEGAL, p.parseIllegal)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
+	p.registerPrefix(token.TRUE, p.parseBoolean)
+	p.registerPrefix(token.FALSE, p.parseBoolean)
+	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
+	p.registerPrefix(token.IF, p.parseIfExpression)
+	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
+	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
+	// blocks are only parsed where the grammar requires one (after if, else and fn)
+	// so a '{' in expression position always starts a hash literal
+	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
 
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
 	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
+	p.registerInfix(token.POWER, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.LT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.GT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.AND, p.parseInfixExpression)
+	p.registerInfix(token.OR, p.parseInfixExpression)
+	// a '(' after an expression calls it, so add(1)(2) and fn(x) { x }(5) both work
+	p.registerInfix(token.LPAREN, p.parseCallExpression)
+	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
 
 	// Read two tokens so both curToken and peekToken are set
 	p.nextToken()
@@ -75,14 +154,44 @@ func New(l *lexer.Lexer) *Parser {
 }
 
 // getter for Parser errors
-func (p *Parser) Errors() []string {
+func (p *Parser) Errors() ErrorList {
 	return p.errors
 }
 
 // on first call will set only peekToken. On second will set curToken as well
 func (p *Parser) nextToken() {
+	p.prevToken = p.curToken
 	p.curToken = p.peekToken
-	p.peekToken = p.l.NextToken()
+
+	if n := len(p.pending); n > 0 {
+		p.peekToken = p.pending[n-1]
+		p.pending = p.pending[:n-1]
+	} else {
+		p.peekToken = p.l.NextToken()
+
+		for p.peekToken.Type == token.COMMENT {
+			p.comments = append(p.comments, &ast.Comment{Token: p.peekToken})
+			p.peekToken = p.l.NextToken()
+		}
+	}
+
+	p.prevDepth = p.depth
+	switch p.curToken.Type {
+	case token.LBRACE:
+		p.depth += 1
+	case token.RBRACE:
+		if p.depth > 0 {
+			p.depth -= 1
+		}
+	}
+}
+
+// undoes the last nextToken, it can't be used twice in a row
+func (p *Parser) backup() {
+	p.depth = p.prevDepth
+	p.pending = append(p.pending, p.peekToken)
+	p.peekToken = p.curToken
+	p.curToken = p.prevToken
 }
 
 // Will return the root of an ast
@@ -94,26 +203,133 @@ func (p *Parser) ParseProgram() *ast.Program {
 	for !p.curTokenIs(token.EOF) {
 		stmt := p.parseStatement()
 
-		//TODO nil stmts are getting through this some how
-		if stmt != nil {
-			program.Statements = append(program.Statements, stmt)
-			fmt.Println(program.Statements)
-		}
+		program.Statements = append(program.Statements, stmt)
 		p.nextToken()
 	}
+
+	p.addLexerErrors()
+	program.Comments = p.comments
+
 	return program
 }
 
-// takes in curToken.Type and chooses the algorithm needed to parse the statement
+// the lexer has already reported why each ILLEGAL token is illegal,
+// merge those reports in with ours so they come out in source order
+func (p *Parser) addLexerErrors() {
+	for _, err := range p.l.Errors() {
+		p.errors = append(p.errors, &ParseError{
+			Pos:    err.Pos,
+			End:    err.End,
+			Actual: token.ILLEGAL,
+			Msg:    err.Msg,
+			Code:   ErrIllegalToken,
+		})
+	}
+	p.errors.Sort()
+}
+
+// takes in curToken.Type and chooses the algorithm needed to parse the statement.
+// if the statement has a syntax error the parser skips to the end of it, so one
+// mistake gives one error and parsing picks up again at the next statement.
+// never returns nil, a statement that couldn't be built at all is a BadStatement
 func (p *Parser) parseStatement() ast.Statement {
+	start := p.curToken
+	// the depth outside the statement, not counting the '{' of a hash literal starting it
+	startDepth := p.depth
+	if p.curTokenIs(token.LBRACE) {
+		startDepth -= 1
+	}
+	failures := p.failures
+	// a statement nested in a block of one that has already failed stays quiet
+	outerRecovering := p.recovering
+	outerStart := p.stmtStart
+	p.stmtStart = start.Pos
+
+	var stmt ast.Statement
 	switch p.curToken.Type {
 	case token.LET:
-		return p.parseLetStatement()
+		// only assign a non-nil pointer, a nil *LetStatement in a Statement isn't nil
+		if let := p.parseLetStatement(); let != nil {
+			stmt = let
+		}
 	case token.RETURN:
-		return p.parseReturnStatement()
+		stmt = p.parseReturnStatement()
 	default:
-		return p.parseExpressionStatement()
+		stmt = p.parseExpressionStatement()
+	}
+
+	if p.failures > failures {
+		p.synchronize(startDepth)
+		if stmt == nil {
+			stmt = &ast.BadStatement{Token: start, From: start.Pos, To: p.curToken.End}
+		}
+	}
+	p.recovering = outerRecovering
+	p.stmtStart = outerStart
+
+	return stmt
+}
+
+// skips to the last token of the failed statement: a ';', or the token before the
+// next let, return, or the '}' closing the block the statement is in. braces opened
+// inside the statement are skipped as a whole so their contents don't restart parsing
+func (p *Parser) synchronize(depth int) {
+	// the error was found at the start of the next statement, as in "let x = 1 +" followed
+	// by a let on the next line, give it back so that statement is parsed as normal
+	if p.depth == depth && p.curToken.Pos != p.stmtStart &&
+		(p.curTokenIs(token.LET) || p.curTokenIs(token.RETURN)) {
+		p.backup()
+		return
+	}
+	// nothing can start with the statement's first token, as with the ')' in "1; ) 2",
+	// so it is all there is to skip
+	if p.depth == depth && p.curToken.Pos == p.stmtStart && p.prefixParseFns[p.curToken.Type] == nil &&
+		!p.curTokenIs(token.LET) && !p.curTokenIs(token.RETURN) {
+		return
+	}
+
+	for !p.curTokenIs(token.EOF) {
+		if p.depth < depth {
+			// went past the end of the enclosing block, give its '}' back
+			if p.depth == depth-1 && p.curTokenIs(token.RBRACE) {
+				p.backup()
+			}
+			return
+		}
+		if p.depth == depth {
+			if p.curTokenIs(token.SEMICOLON) {
+				return
+			}
+			switch p.peekToken.Type {
+			case token.LET, token.RETURN, token.RBRACE:
+				return
+			}
+		}
+		if p.peekTokenIs(token.EOF) {
+			return
+		}
+		p.nextToken()
+	}
+}
+
+// stands in for an expression that failed to parse, from start up to the current token
+func (p *Parser) badExpression(start token.Token) ast.Expression {
+	return &ast.BadExpression{Token: start, From: start.Pos, To: p.curToken.End}
+}
+
+// records err unless one has already been recorded for the statement being parsed
+func (p *Parser) addError(err *ParseError) {
+	p.failures += 1
+	if p.recovering {
+		return
+	}
+	p.recovering = true
+
+	// the lexer has already said why the token is illegal
+	if err.Actual == token.ILLEGAL {
+		return
 	}
+	p.errors = append(p.errors, err)
 }
 
 func (p *Parser) parseLetStatement() *ast.LetStatement {
@@ -131,9 +347,13 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 		return nil
 	}
 
-	//TODO: We will skip the expressions until we encounter a semicolon
+	// advance past the '=' so curToken is the start of the value
+	p.nextToken()
 
-	for !p.curTokenIs(token.SEMICOLON) {
+	stmt.Value = p.parseExpression(LOWEST)
+
+	// semicolon is optional, same as in parseExpressionStatement
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
@@ -143,17 +363,21 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt := &ast.ReturnStatement{Token: p.curToken}
 
+	// advance past 'return' so curToken is the start of the return value
 	p.nextToken()
 
-	if !p.expectPeek(token.SEMICOLON) {
-		return nil
+	stmt.ReturnValue = p.parseExpression(LOWEST)
+
+	// semicolon is optional, same as in parseExpressionStatement
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
 	}
 
 	return stmt
 }
 
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
-	defer untrace(trace("parseExpressionStatement"))
+	defer p.untrace(p.trace("parseExpressionStatement"))
 	stmt := &ast.ExpressionStatement{Token: p.curToken}
 
 	stmt.Expression = p.parseExpression(LOWEST)
@@ -168,18 +392,32 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
 	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	p.addError(&ParseError{
+		Pos:    p.curToken.Pos,
+		End:    p.curToken.End,
+		Actual: t,
+		Msg:    msg,
+		Code:   ErrNoPrefixParseFn,
+	})
 }
 
 // the heart of the Pratt Parser
 func (p *Parser) parseExpression(precedence int) ast.Expression {
-	defer untrace(trace("parseExpression"))
+	defer p.untrace(p.trace("parseExpression"))
 	// retrieve prefix fn
 	// if token type is just an int, it will return parseIntegerLiteral
 	prefix := p.prefixParseFns[p.curToken.Type]
 	if prefix == nil {
 		p.noPrefixParseFnError(p.curToken.Type)
-		return nil
+		bad := p.badExpression(p.curToken)
+		// an operand is missing before a closing bracket, as in "(1 + )", give the
+		// bracket back so whatever it closes can still end there. a '}' that closes
+		// nothing is left alone, and so is one that starts the statement since
+		// going back past it would parse it again
+		if p.closesGroup() && p.curToken.Pos != p.stmtStart {
+			p.backup()
+		}
+		return bad
 	}
 	leftExp := prefix()
 
@@ -228,14 +466,50 @@ func (p *Parser) parseIdentifier() ast.Expression {
 }
 
 func (p *Parser) parseIntegerLiteral() ast.Expression {
-	defer untrace(trace("parseIntegerLiteral"))
+	defer p.untrace(p.trace("parseIntegerLiteral"))
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 
+	// base 0 lets strconv handle the 0x, 0o and 0b prefixes and the underscores
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	if err == nil {
+		lit.Value = value
+		return lit
+	}
+
+	// too large for an int64, keep it as a big integer instead
+	if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+		if bigValue, ok := new(big.Int).SetString(p.curToken.Literal, 0); ok {
+			lit.Big = bigValue
+			return lit
+		}
+	}
+
+	msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
+	p.addError(&ParseError{
+		Pos:    p.curToken.Pos,
+		End:    p.curToken.End,
+		Actual: p.curToken.Type,
+		Msg:    msg,
+		Code:   ErrInvalidInteger,
+	})
+	return p.badExpression(p.curToken)
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.curToken}
+
+	// the lexer only lets well formed floats through, so this fails on overflow like 1e400
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
-		return nil
+		msg := fmt.Sprintf("could not parse %q as float", p.curToken.Literal)
+		p.addError(&ParseError{
+			Pos:    p.curToken.Pos,
+			End:    p.curToken.End,
+			Actual: p.curToken.Type,
+			Msg:    msg,
+			Code:   ErrInvalidFloat,
+		})
+		return p.badExpression(p.curToken)
 	}
 
 	lit.Value = value
@@ -243,8 +517,255 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	return lit
 }
 
+func (p *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+}
+
+// the lexer records an error for every ILLEGAL token it emits, so there is nothing to add here
+func (p *Parser) parseIllegal() ast.Expression {
+	return p.badExpression(p.curToken)
+}
+
+func (p *Parser) parseBoolean() ast.Expression {
+	return &ast.Boolean{Token: p.curToken, Value: p.curTokenIs(token.TRUE)}
+}
+
+// parentheses don't get their own node, they only reset the precedence
+// back to LOWEST so everything up to the ')' binds tighter than the surroundings
+func (p *Parser) parseGroupedExpression() ast.Expression {
+	start := p.curToken
+	p.nextToken()
+
+	exp := p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return p.badExpression(start)
+	}
+
+	return exp
+}
+
+// if (<condition>) { <consequence> } else { <alternative> }
+// the else branch is optional and may itself be another if expression
+func (p *Parser) parseIfExpression() ast.Expression {
+	expression := &ast.IfExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return p.badExpression(expression.Token)
+	}
+
+	p.nextToken()
+	expression.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return p.badExpression(expression.Token)
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return p.badExpression(expression.Token)
+	}
+
+	expression.Consequence = p.parseBlockStatement()
+
+	if !p.peekTokenIs(token.ELSE) {
+		return expression
+	}
+	p.nextToken()
+
+	if p.peekTokenIs(token.IF) {
+		p.nextToken()
+
+		// wrap the nested if in a block so an alternative is always a block
+		block := &ast.BlockStatement{Token: p.curToken}
+		nested := p.parseIfExpression()
+		block.Statements = []ast.Statement{
+			&ast.ExpressionStatement{Token: block.Token, Expression: nested},
+		}
+		expression.Alternative = block
+
+		return expression
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return p.badExpression(expression.Token)
+	}
+
+	expression.Alternative = p.parseBlockStatement()
+
+	return expression
+}
+
+// parses statements until the closing '}', curToken must be the opening '{'
+func (p *Parser) parseBlockStatement() *ast.BlockStatement {
+	block := &ast.BlockStatement{Token: p.curToken}
+	block.Statements = []ast.Statement{}
+
+	p.nextToken()
+
+	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
+		stmt := p.parseStatement()
+		block.Statements = append(block.Statements, stmt)
+		p.nextToken()
+	}
+
+	// ran out of input before the block was closed
+	if p.curTokenIs(token.EOF) {
+		p.unexpectedTokenError(token.RBRACE, p.curToken)
+	} else {
+		block.Rbrace = p.curToken.Pos
+	}
+
+	return block
+}
+
+// fn(<parameters>) { <body> }
+func (p *Parser) parseFunctionLiteral() ast.Expression {
+	lit := &ast.FunctionLiteral{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return p.badExpression(lit.Token)
+	}
+
+	lit.Parameters = p.parseFunctionParameters()
+	if lit.Parameters == nil {
+		return p.badExpression(lit.Token)
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return p.badExpression(lit.Token)
+	}
+
+	lit.Body = p.parseBlockStatement()
+
+	return lit
+}
+
+// parses a comma separated list of identifiers, curToken must be the '('
+// returns nil if the list is malformed
+func (p *Parser) parseFunctionParameters() []*ast.Identifier {
+	identifiers := []*ast.Identifier{}
+
+	// empty parameter list
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return identifiers
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	identifiers = append(identifiers, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		identifiers = append(identifiers, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return identifiers
+}
+
+// curToken is the '(' and function is whatever expression came before it
+func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
+	exp := &ast.CallExpression{Token: p.curToken, Function: function}
+	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	if exp.Arguments == nil {
+		return p.badExpression(exp.Token)
+	}
+	return exp
+}
+
+// [<expression>, <expression>, ...]
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{Token: p.curToken}
+	array.Elements = p.parseExpressionList(token.RBRACKET)
+	if array.Elements == nil {
+		return p.badExpression(array.Token)
+	}
+	return array
+}
+
+// {<expression>: <expression>, ...}
+func (p *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: p.curToken}
+	hash.Pairs = []*ast.HashPair{}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		key := p.parseExpression(LOWEST)
+
+		if !p.expectPeek(token.COLON) {
+			return p.badExpression(hash.Token)
+		}
+
+		p.nextToken()
+		value := p.parseExpression(LOWEST)
+
+		hash.Pairs = append(hash.Pairs, &ast.HashPair{Key: key, Value: value})
+
+		// pairs are separated by commas, a trailing comma before the '}' is allowed
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return p.badExpression(hash.Token)
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return p.badExpression(hash.Token)
+	}
+
+	return hash
+}
+
+// curToken is the '[' and left is the expression being indexed
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+
+	p.nextToken()
+	exp.Index = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RBRACKET) {
+		return p.badExpression(exp.Token)
+	}
+
+	return exp
+}
+
+// parses a comma separated list of expressions up to the end token,
+// curToken must be the opening token. returns nil if the list is malformed
+func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	list := []ast.Expression{}
+
+	// empty list
+	if p.peekTokenIs(end) {
+		p.nextToken()
+		return list
+	}
+
+	p.nextToken()
+	list = append(list, p.parseExpression(LOWEST))
+
+	for p.peekTokenIs(token.COMMA) {
+		// skip over the comma to the start of the next element
+		p.nextToken()
+		p.nextToken()
+		list = append(list, p.parseExpression(LOWEST))
+	}
+
+	if !p.expectPeek(end) {
+		return nil
+	}
+
+	return list
+}
+
 func (p *Parser) parsePrefixExpression() ast.Expression {
-	defer untrace(trace("parsePrefixExpression"))
+	defer p.untrace(p.trace("parsePrefixExpression"))
 	expression := &ast.PrefixExpression{
 		Token:    p.curToken,
 		Operator: p.curToken.Literal,
@@ -262,7 +783,7 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 
 // takes in a left expression and returns an ast.InfixExpression
 func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
-	defer untrace(trace("parseInfixExpression"))
+	defer p.untrace(p.trace("parseInfixExpression"))
 	expression := &ast.InfixExpression{
 		Token:    p.curToken,
 		Operator: p.curToken.Literal,
@@ -270,12 +791,29 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	}
 
 	precedence := p.curPrecedence()
+	// ** is right-associative, parsing its right side one level lower lets
+	// that side take the next ** first, so 2 ** 3 ** 2 is 2 ** (3 ** 2)
+	if p.curTokenIs(token.POWER) {
+		precedence -= 1
+	}
 	p.nextToken()
 	expression.Right = p.parseExpression(precedence)
 
 	return expression
 }
 
+// whether curToken is a ')', ']' or a '}' that has a '{' to close
+func (p *Parser) closesGroup() bool {
+	switch p.curToken.Type {
+	case token.RPAREN, token.RBRACKET:
+		return true
+	case token.RBRACE:
+		return p.prevDepth > 0
+	default:
+		return false
+	}
+}
+
 // get precedence of peekToken
 func (p *Parser) peekPrecedence() int {
 	if p, ok := precedences[p.peekToken.Type]; ok {
@@ -317,8 +855,20 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 
 // adds error to Parser.errors
 func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("expected next token to be %s, got %s instead", t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.unexpectedTokenError(t, p.peekToken)
+}
+
+// records that tok was found where a token of type t was required
+func (p *Parser) unexpectedTokenError(t token.TokenType, tok token.Token) {
+	msg := fmt.Sprintf("expected next token to be %s, got %s instead", t, tok.Type)
+	p.addError(&ParseError{
+		Pos:      tok.Pos,
+		End:      tok.End,
+		Expected: t,
+		Actual:   tok.Type,
+		Msg:      msg,
+		Code:     ErrUnexpectedToken,
+	})
 }
 
 type (
@@ -5,28 +5,36 @@ import (
 	"strings"
 )
 
-var traceLevel int = 0
+// tracing is off unless the parser was made with WithTrace, the indent level is
+// kept on the parser so parsers running at the same time don't mix up each other's
+// indentation
 
 const traceIdentPlaceHolder string = "\t"
 
-func identLevel() string {
-	return strings.Repeat(traceIdentPlaceHolder, traceLevel-1)
+func (p *Parser) identLevel() string {
+	return strings.Repeat(traceIdentPlaceHolder, p.traceLevel-1)
 }
 
-func tracePrint(fs string) {
-	fmt.Printf("%s%s\n", identLevel(), fs)
+func (p *Parser) tracePrint(fs string) {
+	fmt.Fprintf(p.tracer, "%s%s\n", p.identLevel(), fs)
 }
 
-func incIdent() { traceLevel = traceLevel + 1 }
-func decIdent() { traceLevel = traceLevel - 1 }
+func (p *Parser) incIdent() { p.traceLevel = p.traceLevel + 1 }
+func (p *Parser) decIdent() { p.traceLevel = p.traceLevel - 1 }
 
-func trace(msg string) string {
-	incIdent()
-	tracePrint("BEGIN " + msg)
+func (p *Parser) trace(msg string) string {
+	if p.tracer == nil {
+		return msg
+	}
+	p.incIdent()
+	p.tracePrint("BEGIN " + msg)
 	return msg
 }
 
-func untrace(msg string) {
-	tracePrint("END " + msg)
-	decIdent()
+func (p *Parser) untrace(msg string) {
+	if p.tracer == nil {
+		return
+	}
+	p.tracePrint("END " + msg)
+	p.decIdent()
 }
@@ -0,0 +1,470 @@
+// Package printer turns an AST back into Chlorophyll source in its canonical form:
+// one statement per line ending in a semicolon, blocks indented with tabs and
+// only the parentheses the parser needs to build the same tree again
+package printer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/ast"
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/lexer"
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/parser"
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/token"
+)
+
+// binds tighter than any operator, for literals, identifiers and the like
+const atom = parser.INDEX + 1
+
+// no statement or comment starts this far into the source
+const unbounded = int(^uint(0) >> 1)
+
+type printer struct {
+	out    bytes.Buffer
+	indent int
+	// the source the tree was parsed from, nil if it isn't known. it is only
+	// used to see where the blank lines and the comments on their own lines were
+	src []byte
+	// comments of the Program not printed yet, in source order
+	comments []*ast.Comment
+	// line of the last thing printed that came from the source
+	lastLine int
+	// the first node that can't be printed
+	err error
+}
+
+// Fprint writes node to w as canonical source. the comments of a Program
+// are printed between the statements around them
+func Fprint(w io.Writer, node ast.Node) error {
+	p := &printer{}
+	p.node(node)
+	if p.err != nil {
+		return p.err
+	}
+	_, err := w.Write(p.out.Bytes())
+	return err
+}
+
+// Format parses src and returns it formatted. unlike Fprint it also keeps
+// single blank lines between statements. opts are passed on to the lexer,
+// as lexer.WithFilename to name the file in errors. src with syntax errors
+// isn't formatted, the parser's errors are returned instead as a parser.ErrorList
+func Format(src []byte, opts ...lexer.Option) ([]byte, error) {
+	l := lexer.New(string(src), append([]lexer.Option{lexer.WithComments()}, opts...)...)
+	par := parser.New(l)
+	program := par.ParseProgram()
+	if err := par.Errors().Err(); err != nil {
+		return nil, err
+	}
+
+	p := &printer{src: src}
+	p.node(program)
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.out.Bytes(), nil
+}
+
+func (p *printer) node(node ast.Node) {
+	switch node := node.(type) {
+	case *ast.Program:
+		p.comments = node.Comments
+		if p.statementList(node.Statements, unbounded, false) {
+			p.out.WriteByte('\n')
+		}
+	case ast.Statement:
+		p.statement(node)
+	case ast.Expression:
+		p.expression(node, parser.LOWEST, false)
+	default:
+		p.error(fmt.Errorf("printer: can't print node of type %T", node))
+	}
+}
+
+func (p *printer) error(err error) {
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+func (p *printer) print(s string) { p.out.WriteString(s) }
+
+// marks the line of pos as printed, for comments on the same line to follow it
+func (p *printer) mark(pos token.Position) {
+	if pos.Line > p.lastLine {
+		p.lastLine = pos.Line
+	}
+}
+
+// prints the statements of a Program or block one per line, with the comments
+// before end in the source in between them. reports whether any line was printed
+func (p *printer) statementList(list []ast.Statement, end int, block bool) bool {
+	first := true
+	for i, stmt := range list {
+		start := startOf(stmt)
+		p.ownLineComments(start, &first, block)
+		p.newline(start, &first, block)
+		p.statement(stmt)
+
+		next := end
+		if i+1 < len(list) {
+			next = startOf(list[i+1])
+		}
+		p.trailingComments(next)
+	}
+	p.ownLineComments(end, &first, block)
+
+	return !first
+}
+
+// starts the line of the statement or comment at offset in the source, the first
+// line of a block goes after its '{' while the first of a Program starts the output
+func (p *printer) newline(offset int, first *bool, block bool) {
+	if *first {
+		*first = false
+		if block {
+			p.out.WriteByte('\n')
+		}
+	} else {
+		p.out.WriteByte('\n')
+		if p.blankLineBefore(offset) {
+			p.out.WriteByte('\n')
+		}
+	}
+	p.print(strings.Repeat("\t", p.indent))
+}
+
+// comments before end in the source that were on lines of their own
+func (p *printer) ownLineComments(end int, first *bool, block bool) {
+	for len(p.comments) > 0 && p.comments[0].Token.Pos.Offset < end {
+		c := p.comments[0]
+		p.comments = p.comments[1:]
+
+		p.newline(c.Token.Pos.Offset, first, block)
+		p.print(c.Token.Literal)
+		p.mark(c.Token.End)
+	}
+}
+
+// comments before end in the source that followed the code on its line stay there
+func (p *printer) trailingComments(end int) {
+	for len(p.comments) > 0 && p.comments[0].Token.Pos.Offset < end && p.followsCode(p.comments[0]) {
+		c := p.comments[0]
+		p.comments = p.comments[1:]
+
+		p.print(" " + c.Token.Literal)
+		p.mark(c.Token.End)
+	}
+}
+
+func (p *printer) followsCode(c *ast.Comment) bool {
+	if p.src == nil {
+		return c.Token.Pos.Line == p.lastLine
+	}
+	for i := c.Token.Pos.Offset - 1; i >= 0 && i < len(p.src); i-- {
+		switch p.src[i] {
+		case ' ', '\t', '\r':
+			continue
+		case '\n':
+			return false
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// at most one blank line is kept, and only when the source is known
+func (p *printer) blankLineBefore(offset int) bool {
+	if p.src == nil || offset > len(p.src) {
+		return false
+	}
+	newlines := 0
+	for i := offset - 1; i >= 0; i-- {
+		switch p.src[i] {
+		case ' ', '\t', '\r':
+			continue
+		case '\n':
+			newlines += 1
+			continue
+		}
+		break
+	}
+	return newlines > 1
+}
+
+// offset of the first token of stmt in the source
+func startOf(stmt ast.Statement) int {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		return stmt.Token.Pos.Offset
+	case *ast.ReturnStatement:
+		return stmt.Token.Pos.Offset
+	case *ast.ExpressionStatement:
+		return stmt.Token.Pos.Offset
+	case *ast.BlockStatement:
+		return stmt.Token.Pos.Offset
+	case *ast.BadStatement:
+		return stmt.From.Offset
+	}
+	return 0
+}
+
+func (p *printer) statement(stmt ast.Statement) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		p.mark(stmt.Token.Pos)
+		p.print("let ")
+		p.expression(stmt.Name, parser.LOWEST, false)
+		p.print(" = ")
+		if stmt.Value == nil {
+			p.error(fmt.Errorf("printer: let statement for %s has no value", stmt.Name))
+		} else {
+			p.expression(stmt.Value, parser.LOWEST, false)
+		}
+		p.print(";")
+
+	case *ast.ReturnStatement:
+		p.mark(stmt.Token.Pos)
+		p.print("return ")
+		if stmt.ReturnValue == nil {
+			p.error(fmt.Errorf("printer: return statement has no value"))
+		} else {
+			p.expression(stmt.ReturnValue, parser.LOWEST, false)
+		}
+		p.print(";")
+
+	case *ast.ExpressionStatement:
+		// every expression statement ends in a semicolon, otherwise a statement
+		// starting with '(' or '-' would be taken as a call or subtraction
+		if stmt.Expression == nil {
+			p.error(fmt.Errorf("printer: expression statement has no expression"))
+		} else {
+			p.expression(stmt.Expression, parser.LOWEST, false)
+		}
+		p.print(";")
+
+	case *ast.BlockStatement:
+		p.block(stmt)
+
+	case *ast.BadStatement:
+		p.error(fmt.Errorf("printer: syntax error at %s", stmt.From))
+
+	default:
+		p.error(fmt.Errorf("printer: can't print statement of type %T", stmt))
+	}
+}
+
+func (p *printer) block(block *ast.BlockStatement) {
+	if block == nil {
+		p.error(fmt.Errorf("printer: missing block"))
+		return
+	}
+	p.mark(block.Token.Pos)
+	p.print("{")
+
+	// comments after the last statement stay in the block when it's known where it ends
+	end := 0
+	if block.Rbrace.IsValid() {
+		end = block.Rbrace.Offset
+	}
+
+	p.indent += 1
+	printed := p.statementList(block.Statements, end, true)
+	p.indent -= 1
+
+	if printed {
+		p.print("\n" + strings.Repeat("\t", p.indent))
+	}
+	p.print("}")
+	p.mark(block.Rbrace)
+}
+
+// precedence of exp as an operand, how tightly it holds together
+func precedence(exp ast.Expression) int {
+	switch exp := exp.(type) {
+	case *ast.InfixExpression:
+		return parser.Precedence(token.TokenType(exp.Operator))
+	case *ast.PrefixExpression:
+		return parser.PREFIX
+	case *ast.CallExpression:
+		return parser.CALL
+	case *ast.IndexExpression:
+		return parser.INDEX
+	}
+	// if and fn have their own closing '}', so they are as good as literals
+	return atom
+}
+
+// prints exp, in parentheses if it binds less tightly than prec. last is set
+// when exp ends the operator expression it's in, then a prefix expression needs
+// none: the parser reads it with its prefix function wherever it is
+func (p *printer) expression(exp ast.Expression, prec int, last bool) {
+	_, isPrefix := exp.(*ast.PrefixExpression)
+	if precedence(exp) < prec && !(last && isPrefix) {
+		p.print("(")
+		p.expression(exp, parser.LOWEST, false)
+		p.print(")")
+		return
+	}
+
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		p.mark(exp.Token.Pos)
+		p.print(exp.Value)
+
+	case *ast.IntegerLiteral:
+		p.mark(exp.Token.Pos)
+		switch {
+		case exp.Token.Literal != "":
+			// keeps the base it was written in
+			p.print(exp.Token.Literal)
+		case exp.Big != nil:
+			p.print(exp.Big.String())
+		default:
+			p.print(strconv.FormatInt(exp.Value, 10))
+		}
+
+	case *ast.FloatLiteral:
+		p.mark(exp.Token.Pos)
+		if exp.Token.Literal != "" {
+			p.print(exp.Token.Literal)
+			break
+		}
+		if math.IsInf(exp.Value, 0) || math.IsNaN(exp.Value) {
+			p.error(fmt.Errorf("printer: %v has no literal", exp.Value))
+			break
+		}
+		s := strconv.FormatFloat(exp.Value, 'g', -1, 64)
+		// a float has to be told apart from an integer
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		p.print(s)
+
+	case *ast.StringLiteral:
+		p.mark(exp.Token.Pos)
+		p.print(ast.Quote(exp.Value))
+
+	case *ast.Boolean:
+		p.mark(exp.Token.Pos)
+		p.print(strconv.FormatBool(exp.Value))
+
+	case *ast.PrefixExpression:
+		p.mark(exp.Token.Pos)
+		p.print(exp.Operator)
+		p.expression(exp.Right, parser.PREFIX+1, true)
+
+	case *ast.InfixExpression:
+		// the right operand is read at the operator's own precedence, so an
+		// operator of the same precedence on that side needs parentheses. '**' is
+		// right associative and the other way around
+		level := parser.Precedence(token.TokenType(exp.Operator))
+		left, right := level, level+1
+		if exp.Operator == token.POWER {
+			left, right = level+1, level
+		}
+		p.expression(exp.Left, left, false)
+		p.mark(exp.Token.Pos)
+		p.print(" " + exp.Operator + " ")
+		p.expression(exp.Right, right, true)
+
+	case *ast.IfExpression:
+		p.mark(exp.Token.Pos)
+		p.print("if (")
+		p.expression(exp.Condition, parser.LOWEST, false)
+		p.print(") ")
+		p.block(exp.Consequence)
+		if exp.Alternative != nil {
+			p.print(" else ")
+			if nested := elseIf(exp.Alternative); nested != nil {
+				p.expression(nested, parser.LOWEST, false)
+			} else {
+				p.block(exp.Alternative)
+			}
+		}
+
+	case *ast.FunctionLiteral:
+		p.mark(exp.Token.Pos)
+		p.print("fn(")
+		for i, param := range exp.Parameters {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.expression(param, parser.LOWEST, false)
+		}
+		p.print(") ")
+		p.block(exp.Body)
+
+	case *ast.CallExpression:
+		// a call or index after the function keeps the chain going, anything
+		// else would take the '(' for its own last operand
+		p.expression(exp.Function, parser.CALL, false)
+		p.mark(exp.Token.Pos)
+		p.print("(")
+		p.expressionList(exp.Arguments)
+		p.print(")")
+
+	case *ast.ArrayLiteral:
+		p.mark(exp.Token.Pos)
+		p.print("[")
+		p.expressionList(exp.Elements)
+		p.print("]")
+
+	case *ast.IndexExpression:
+		p.expression(exp.Left, parser.CALL, false)
+		p.mark(exp.Token.Pos)
+		p.print("[")
+		p.expression(exp.Index, parser.LOWEST, false)
+		p.print("]")
+
+	case *ast.HashLiteral:
+		p.mark(exp.Token.Pos)
+		p.print("{")
+		for i, pair := range exp.Pairs {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.expression(pair.Key, parser.LOWEST, false)
+			p.print(": ")
+			p.expression(pair.Value, parser.LOWEST, false)
+		}
+		p.print("}")
+
+	case *ast.BadExpression:
+		p.error(fmt.Errorf("printer: syntax error at %s", exp.From))
+
+	case nil:
+		p.error(fmt.Errorf("printer: missing expression"))
+
+	default:
+		p.error(fmt.Errorf("printer: can't print expression of type %T", exp))
+	}
+}
+
+func (p *printer) expressionList(list []ast.Expression) {
+	for i, exp := range list {
+		if i > 0 {
+			p.print(", ")
+		}
+		p.expression(exp, parser.LOWEST, false)
+	}
+}
+
+// the parser stores the if of an else-if in a block started by that 'if',
+// returns the nested if when block is one of those
+func elseIf(block *ast.BlockStatement) *ast.IfExpression {
+	if block.Token.Type != token.IF || len(block.Statements) != 1 {
+		return nil
+	}
+	stmt, ok := block.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		return nil
+	}
+	nested, _ := stmt.Expression.(*ast.IfExpression)
+	return nested
+}
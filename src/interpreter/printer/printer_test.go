@@ -0,0 +1,245 @@
+package printer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/ast"
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/lexer"
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/parser"
+)
+
+func TestMinimalParentheses(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(-a) * b", "-a * b;\n"},
+		{"a + (b * c)", "a + b * c;\n"},
+		{"(a + b) * c", "(a + b) * c;\n"},
+		{"(a - b) - c", "a - b - c;\n"},
+		{"a - (b - c)", "a - (b - c);\n"},
+		{"a ** (b ** c)", "a ** b ** c;\n"},
+		{"(a ** b) ** c", "(a ** b) ** c;\n"},
+		{"(-a) ** 2", "(-a) ** 2;\n"},
+		{"-(a ** 2)", "-a ** 2;\n"},
+		{"a ** (-b)", "a ** -b;\n"},
+		{"a * (-b) + c", "a * -b + c;\n"},
+		{"-(-a)", "--a;\n"},
+		{"!(a == b)", "!(a == b);\n"},
+		{"(a < b) == (c >= d)", "a < b == c >= d;\n"},
+		{"a || (b && c)", "a || b && c;\n"},
+		{"(a || b) && c", "(a || b) && c;\n"},
+		{"(a % b) / c", "a % b / c;\n"},
+		{"(a + b)(c)", "(a + b)(c);\n"},
+		{"(-f)(x)", "(-f)(x);\n"},
+		{"-(f(x))", "-f(x);\n"},
+		{"(f(1))[0]", "f(1)[0];\n"},
+		{"((a[0])[1])(2)", "a[0][1](2);\n"},
+		{"(a + b)[0]", "(a + b)[0];\n"},
+		{"f((a + b), [(1), (2 * 3)], {(1): (2)})", "f(a + b, [1, 2 * 3], {1: 2});\n"},
+		{"(fn(x) { x })(1)", "fn(x) {\n\tx;\n}(1);\n"},
+	}
+
+	for _, tt := range tests {
+		actual, err := Format([]byte(tt.input))
+		if err != nil {
+			t.Fatalf("Format(%q) returned error: %s", tt.input, err)
+		}
+		if string(actual) != tt.expected {
+			t.Errorf("Format(%q) wrong.\nexpected=%q\ngot=     %q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestFormatLayout(t *testing.T) {
+	input := `// adds things up
+let add=fn(a,b){return a+b}
+
+
+let max = fn(a, b) { if (a > b) { a } else if (a == b) { a } else { b } } // the larger
+let h = {"one" : [1,2.5,"tab\t"], "none":{}}
+let nothing = fn() {}
+if (true) {
+  // nothing to do
+  let x = 1; /* but this */
+
+  x
+  // all done
+}
+add(1, 2)
+/* the end */
+`
+	expected := `// adds things up
+let add = fn(a, b) {
+	return a + b;
+};
+
+let max = fn(a, b) {
+	if (a > b) {
+		a;
+	} else if (a == b) {
+		a;
+	} else {
+		b;
+	};
+}; // the larger
+let h = {"one": [1, 2.5, "tab\t"], "none": {}};
+let nothing = fn() {};
+if (true) {
+	// nothing to do
+	let x = 1; /* but this */
+
+	x;
+	// all done
+};
+add(1, 2);
+/* the end */
+`
+
+	actual, err := Format([]byte(input))
+	if err != nil {
+		t.Fatalf("Format returned error: %s", err)
+	}
+	if string(actual) != expected {
+		t.Errorf("Format wrong.\nexpected=\n%s\ngot=\n%s", expected, actual)
+	}
+}
+
+// formatting twice changes nothing, and the tree is the same as the one
+// the original source gives
+func TestFormatIdempotent(t *testing.T) {
+	tests := []string{
+		"let x = 5; return x; x;",
+		"-a * b ** 2 % c <= d && !e || f >= 1.5",
+		`let add = fn(a, b) { return a + b; }; add(1, 2)(3)`,
+		`if (x < y) { x } else if (x > y) { y } else { "same\n" }`,
+		`fn() {}; [1, "two", [3]][0]; {}; {"k": true, 2: false}["k"]`,
+		"99999999999999999999999999 + 0x10 - 0b11 * 1e3",
+		"// a\nlet x = 1; // b\n\n\n/* c */ let y = fn() {\n// d\n\n\n x // e\n // f\n};\n// g",
+		"let f = fn(x) { if (x) { 1 } }; f(-(-1))",
+	}
+
+	for _, input := range tests {
+		once, err := Format([]byte(input))
+		if err != nil {
+			t.Fatalf("Format(%q) returned error: %s", input, err)
+		}
+		twice, err := Format(once)
+		if err != nil {
+			t.Fatalf("Format of formatted %q returned error: %s\n%s", input, err, once)
+		}
+		if !bytes.Equal(once, twice) {
+			t.Errorf("Format not idempotent for %q.\nonce=\n%s\ntwice=\n%s", input, once, twice)
+		}
+
+		original := parser.New(lexer.New(input)).ParseProgram().String()
+		formatted := parser.New(lexer.New(string(once))).ParseProgram().String()
+		if original != formatted {
+			t.Errorf("Format changed the tree of %q.\nexpected=%q\ngot=     %q", input, original, formatted)
+		}
+	}
+}
+
+// "\r\n" line endings come out as "\n" everywhere, comments included
+func TestFormatCRLF(t *testing.T) {
+	actual, err := Format([]byte("// a\r\nlet x = 1; // b\r\n\r\n\r\nx\r\n"))
+	if err != nil {
+		t.Fatalf("Format returned error: %s", err)
+	}
+	expected := "// a\nlet x = 1; // b\n\nx;\n"
+	if string(actual) != expected {
+		t.Errorf("Format wrong. expected=%q, got=%q", expected, actual)
+	}
+}
+
+func TestFormatSyntaxError(t *testing.T) {
+	_, err := Format([]byte("let x = ;\nlet y = 2;"))
+	if err == nil {
+		t.Fatalf("Format did not return an error for invalid source")
+	}
+	expected := "1:9: no prefix parse function for ; found"
+	if err.Error() != expected {
+		t.Errorf("wrong error. expected=%q, got=%q", expected, err.Error())
+	}
+
+	// the lexer options name the file, and every error comes back in the list
+	_, err = Format([]byte("let x = ;\nlet = 2;"), lexer.WithFilename("x.chl"))
+	errs, ok := err.(parser.ErrorList)
+	if !ok {
+		t.Fatalf("error is not a parser.ErrorList. got=%T (%v)", err, err)
+	}
+	if len(errs) != 2 || errs[0].Pos.String() != "x.chl:1:9" || errs[1].Pos.String() != "x.chl:2:5" {
+		t.Errorf("wrong errors. got=%v", []*parser.ParseError(errs))
+	}
+}
+
+// trees built or rewritten in code have no source to go by
+func TestFprint(t *testing.T) {
+	program := &ast.Program{
+		Statements: []ast.Statement{
+			&ast.LetStatement{
+				Name: &ast.Identifier{Value: "x"},
+				Value: &ast.InfixExpression{
+					Left:     &ast.InfixExpression{Left: &ast.IntegerLiteral{Value: 1}, Operator: "+", Right: &ast.FloatLiteral{Value: 2}},
+					Operator: "*",
+					Right:    &ast.StringLiteral{Value: "a\"b"},
+				},
+			},
+			&ast.ExpressionStatement{Expression: &ast.FunctionLiteral{
+				Parameters: []*ast.Identifier{{Value: "y"}},
+				Body: &ast.BlockStatement{Statements: []ast.Statement{
+					&ast.ReturnStatement{ReturnValue: &ast.Boolean{Value: true}},
+				}},
+			}},
+		},
+	}
+
+	var out bytes.Buffer
+	if err := Fprint(&out, program); err != nil {
+		t.Fatalf("Fprint returned error: %s", err)
+	}
+
+	expected := "let x = (1 + 2.0) * \"a\\\"b\";\nfn(y) {\n\treturn true;\n};\n"
+	if out.String() != expected {
+		t.Errorf("Fprint wrong.\nexpected=%q\ngot=     %q", expected, out.String())
+	}
+}
+
+func TestFprintComments(t *testing.T) {
+	input := "let x = 1; // one\n// two\nx"
+	program := parser.New(lexer.New(input, lexer.WithComments())).ParseProgram()
+
+	var out bytes.Buffer
+	if err := Fprint(&out, program); err != nil {
+		t.Fatalf("Fprint returned error: %s", err)
+	}
+
+	expected := "let x = 1; // one\n// two\nx;\n"
+	if out.String() != expected {
+		t.Errorf("Fprint wrong.\nexpected=%q\ngot=     %q", expected, out.String())
+	}
+}
+
+func TestFprintErrors(t *testing.T) {
+	tests := []struct {
+		node     ast.Node
+		expected string
+	}{
+		{parser.New(lexer.New("f(1, )")).ParseProgram(), "printer: syntax error at 1:6"},
+		{&ast.LetStatement{Name: &ast.Identifier{Value: "x"}}, "printer: let statement for x has no value"},
+		{&ast.FunctionLiteral{}, "printer: missing block"},
+		{&ast.PrefixExpression{Operator: "-"}, "printer: missing expression"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		err := Fprint(&out, tt.node)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("wrong error for %T. expected=%q, got=%v", tt.node, tt.expected, err)
+		}
+		if out.Len() != 0 {
+			t.Errorf("Fprint wrote output despite the error. got=%q", out.String())
+		}
+	}
+}
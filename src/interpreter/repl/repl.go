@@ -3,33 +3,90 @@ package repl
 import (
 	"bufio"
 	"fmt"
-	"github.com/alex-davis-808/go-interpreter/src/interpreter/lexer"
-	"github.com/alex-davis-808/go-interpreter/src/interpreter/token"
 	"io"
+	"strings"
+
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/ast"
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/evaluator"
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/lexer"
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/object"
+	"github.com/alex-davis-808/go-interpreter/src/interpreter/parser"
 )
 
 const PROMPT = ">> "
 
 func Start(in io.Reader, out io.Writer) {
 	scanner := bufio.NewScanner(in)
+	// one environment for the whole session so bindings survive between lines
+	env := object.NewEnvironment()
 
 	// Infinite while loop
 	for {
-		fmt.Printf(PROMPT)
+		fmt.Fprint(out, PROMPT)
 		// read from line until encountering a newline
 		scanned := scanner.Scan()
 		if !scanned {
 			return
 		}
 
-		// Grab text from scanner and pass it into lexer
+		// Grab text from scanner and parse it into a program
 		line := scanner.Text()
 
+		if strings.HasPrefix(line, ":") {
+			runCommand(out, line)
+			continue
+		}
+
 		l := lexer.New(line)
+		p := parser.New(l)
 
-		// iterate through tokens, printing one by one
-		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
-			fmt.Printf("%+v\n", tok)
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			printParserErrors(out, line, p.Errors())
+			continue
 		}
+
+		// statements like let produce no value, so there is nothing to print
+		evaluated := evaluator.Eval(program, env)
+		if evaluated != nil {
+			io.WriteString(out, evaluated.Inspect())
+			io.WriteString(out, "\n")
+		}
+	}
+}
+
+// prints each error with the offending part of the line underlined
+func printParserErrors(out io.Writer, line string, errors parser.ErrorList) {
+	io.WriteString(out, errors.Render(line))
+}
+
+// commands start with ':' and are followed by the source they work on,
+// ":tree -a * b" shows how the parser nested the expression
+func runCommand(out io.Writer, line string) {
+	parts := strings.SplitN(line, " ", 2)
+	name, src := parts[0], ""
+	if len(parts) == 2 {
+		src = parts[1]
+	}
+
+	var dump func(io.Writer, ast.Node) error
+	switch name {
+	case ":tree":
+		dump = ast.Fprint
+	case ":dot":
+		dump = ast.FprintDOT
+	default:
+		fmt.Fprintf(out, "unknown command %s, the commands are :tree and :dot\n", name)
+		return
+	}
+
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		printParserErrors(out, src, p.Errors())
+		return
+	}
+	if err := dump(out, program); err != nil {
+		fmt.Fprintln(out, err)
 	}
 }
@@ -0,0 +1,106 @@
+package repl
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"5 + 5\n",
+			">> 10\n>> ",
+		},
+		// bindings persist from one line to the next
+		{
+			"let x = 5;\nx * 2\n",
+			">> >> 10\n>> ",
+		},
+		{
+			"let add = fn(a, b) { a + b };\nadd(1, 2)\n",
+			">> >> 3\n>> ",
+		},
+		{
+			"true + 1\n",
+			">> ERROR: type mismatch: BOOLEAN + INTEGER\n>> ",
+		},
+		{
+			"let x 5;\n",
+			">> 1:7: expected next token to be =, got INT instead\nlet x 5;\n      ^\n>> ",
+		},
+		{
+			":tree -a * b\n",
+			">> Program\n" +
+				".  statements[0]: ExpressionStatement 1:1\n" +
+				".  .  expression: InfixExpression \"*\" 1:4\n" +
+				".  .  .  left: PrefixExpression \"-\" 1:1\n" +
+				".  .  .  .  right: Identifier a 1:2\n" +
+				".  .  .  right: Identifier b 1:6\n>> ",
+		},
+		{
+			":dot x\n",
+			">> digraph ast {\n" +
+				"\tnode [shape=box, fontname=\"monospace\"];\n" +
+				"\tn0 [label=\"Program\"];\n" +
+				"\tn1 [label=\"ExpressionStatement\"];\n" +
+				"\tn2 [label=\"Identifier x\"];\n" +
+				"\tn1 -> n2 [label=\"expression\"];\n" +
+				"\tn0 -> n1 [label=\"statements[0]\"];\n" +
+				"}\n>> ",
+		},
+		// commands don't touch the environment
+		{
+			":tree let x = 1;\nx\n",
+			">> Program\n" +
+				".  statements[0]: LetStatement 1:1\n" +
+				".  .  name: Identifier x 1:5\n" +
+				".  .  value: IntegerLiteral 1 1:9\n" +
+				">> ERROR: identifier not found: x\n>> ",
+		},
+		{
+			":tree let x 5;\n",
+			">> 1:7: expected next token to be =, got INT instead\nlet x 5;\n      ^\n>> ",
+		},
+		{
+			":ast x\n",
+			">> unknown command :ast, the commands are :tree and :dot\n>> ",
+		},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		Start(strings.NewReader(tt.input), &out)
+
+		if out.String() != tt.expected {
+			t.Errorf("wrong output for %q.\nexpected=%q\ngot=     %q", tt.input, tt.expected, out.String())
+		}
+	}
+}
+
+// fails its first write and keeps the rest
+type failOnceWriter struct {
+	failed bool
+	bytes.Buffer
+}
+
+func (w *failOnceWriter) Write(p []byte) (int, error) {
+	if !w.failed {
+		w.failed = true
+		return 0, errors.New("disk full")
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestRunCommandWriteError(t *testing.T) {
+	var out failOnceWriter
+	runCommand(&out, ":tree x")
+
+	if out.String() != "disk full\n" {
+		t.Errorf("dump error not reported. got=%q", out.String())
+	}
+}
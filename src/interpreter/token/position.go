@@ -0,0 +1,34 @@
+package token
+
+import "fmt"
+
+// Position describes a single location in the source being lexed
+type Position struct {
+	Filename string `json:"filename,omitempty"` // optional, empty if the source has no name
+	Offset   int    `json:"offset"`             // byte offset, starting at 0
+	Line     int    `json:"line"`               // line number, starting at 1
+	Column   int    `json:"column"`             // column number, starting at 1 (rune count)
+}
+
+// a Position is only valid once the lexer has stamped a line number on it
+func (p Position) IsValid() bool { return p.Line > 0 }
+
+// String returns the position in one of the following forms:
+//
+//	file:line:column    valid position with file name
+//	line:column         valid position without file name
+//	file                invalid position with file name
+//	-                   invalid position without file name
+func (p Position) String() string {
+	s := p.Filename
+	if p.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	if s == "" {
+		s = "-"
+	}
+	return s
+}
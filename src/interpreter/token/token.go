@@ -3,17 +3,23 @@ package token
 type TokenType string
 
 type Token struct {
-	Type    TokenType
-	Literal string
+	Type    TokenType `json:"type"`
+	Literal string    `json:"literal"`
+	// span of source the token was read from, End is the position just after the last char
+	Pos Position `json:"pos"`
+	End Position `json:"end"`
 }
 
 const (
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF"
+	COMMENT = "COMMENT" // only emitted when the lexer is asked to keep comments
 
 	// Identifiers and literals
-	IDENT = "IDENT" // var names
-	INT   = "INT"   // 1235
+	IDENT  = "IDENT"  // var names
+	INT    = "INT"    // 1235
+	FLOAT  = "FLOAT"  // 12.35, 1e10
+	STRING = "STRING" // "foo bar"
 
 	// Operators
 	ASSIGN   = "="
@@ -22,21 +28,31 @@ const (
 	BANG     = "!"
 	ASTERISK = "*"
 	SLASH    = "/"
+	PERCENT  = "%"
+	POWER    = "**"
 
-	LT = "<"
-	GT = ">"
+	LT    = "<"
+	GT    = ">"
+	LT_EQ = "<="
+	GT_EQ = ">="
 
 	EQ     = "=="
 	NOT_EQ = "!="
 
+	AND = "&&"
+	OR  = "||"
+
 	// Delimiters
 	COMMA     = ","
 	SEMICOLON = ";"
+	COLON     = ":"
 
-	LPAREN = "("
-	RPAREN = ")"
-	LBRACE = "{"
-	RBRACE = "}"
+	LPAREN   = "("
+	RPAREN   = ")"
+	LBRACE   = "{"
+	RBRACE   = "}"
+	LBRACKET = "["
+	RBRACKET = "]"
 
 	// Keywords
 	FUNCTION = "FUNCTION"